@@ -0,0 +1,59 @@
+// Команда reencrypt приводит персональные данные в БД, событиях outbox и архиве
+// (таблица и файлы RETENTION_DIR) к активному ключу из PII_KEYFILE (или
+// PII_ENCRYPTION_KEY): шифрует значения, записанные до включения шифрования,
+// и перешифровывает значения под прежними ключами после ротации.
+// Повторный запуск безопасен: значения под активным ключом не меняются.
+//
+//	PII_KEYFILE=/etc/orders/pii-keys.json go run ./cmd/reencrypt -batch 500
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/config"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/database"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/retention"
+)
+
+func main() {
+	batch := flag.Int("batch", 500, "строк в одной транзакции")
+	flag.Parse()
+
+	if *batch <= 0 {
+		log.Fatal("-batch must be positive")
+	}
+
+	cfg := config.Load()
+	keyring, err := pii.OpenKeyring(cfg.PIIKeyfile, cfg.PIIEncryptionKey)
+	if err != nil {
+		log.Fatal("Failed to load PII keys: ", err)
+	}
+	if keyring == nil {
+		log.Fatal("PII_KEYFILE or PII_ENCRYPTION_KEY is required")
+	}
+
+	db, err := database.Open(cfg.PostgresURL, database.Options{
+		MaxOpenConns:   1,
+		MaxIdleConns:   1,
+		ConnectRetries: cfg.DBConnectRetries,
+		ConnectBackoff: cfg.DBConnectBackoff,
+	})
+	if err != nil {
+		log.Fatal("Failed to connect to database: ", err)
+	}
+
+	rows, err := repository.NewPIIRepository(db).Reencrypt(keyring, *batch)
+	if err != nil {
+		log.Fatalf("Re-encryption failed after %d rows: %v", rows, err)
+	}
+	log.Printf("✅ PII re-encrypted with key %q: %d rows", keyring.Active(), rows)
+
+	records, err := retention.ReencryptFiles(cfg.RetentionDir, keyring)
+	if err != nil {
+		log.Fatalf("Archive re-encryption failed after %d records: %v", records, err)
+	}
+	log.Printf("✅ Archive files in %s re-encrypted: %d records", cfg.RetentionDir, records)
+}
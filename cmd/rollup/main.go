@@ -0,0 +1,56 @@
+// Команда rollup пересчитывает дневные итоги продаж (order_rollups_daily)
+// по сырым данным orders/payment за диапазон дат. Дни с архивными заказами
+// не пересчитываются.
+//
+//	go run ./cmd/rollup -from 2024-01-01 -to 2024-01-31
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/config"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/database"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+)
+
+func main() {
+	fromFlag := flag.String("from", "", "первый день диапазона, YYYY-MM-DD")
+	toFlag := flag.String("to", "", "последний день диапазона включительно, YYYY-MM-DD (по умолчанию сегодня)")
+	flag.Parse()
+
+	if *fromFlag == "" {
+		log.Fatal("-from is required")
+	}
+	from, err := time.Parse(time.DateOnly, *fromFlag)
+	if err != nil {
+		log.Fatal("Invalid -from: ", err)
+	}
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if *toFlag != "" {
+		if to, err = time.Parse(time.DateOnly, *toFlag); err != nil {
+			log.Fatal("Invalid -to: ", err)
+		}
+	}
+	if to.Before(from) {
+		log.Fatal("-to must not be before -from")
+	}
+
+	cfg := config.Load()
+	db, err := database.Open(cfg.PostgresURL, database.Options{
+		MaxOpenConns:   1,
+		MaxIdleConns:   1,
+		ConnectRetries: cfg.DBConnectRetries,
+		ConnectBackoff: cfg.DBConnectBackoff,
+	})
+	if err != nil {
+		log.Fatal("Failed to connect to database: ", err)
+	}
+
+	rows, err := repository.NewRollupRepository(db, cfg.ReportingCurrency).Rebuild(from, to.AddDate(0, 0, 1))
+	if err != nil {
+		log.Fatal("Rebuild failed: ", err)
+	}
+	log.Printf("✅ Rollups rebuilt for %s..%s: %d rows", from.Format(time.DateOnly), to.Format(time.DateOnly), rows)
+}
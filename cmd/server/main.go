@@ -1,22 +1,36 @@
 package main
 
 import (
+	"context"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
+	"github.com/Sphirium/wb-tech-demo-lo/internal/auth"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/breaker"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/cache"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/config"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/consumer"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/database"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/handler"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/health"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/money"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/outbox"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/partition"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/ratelimit"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/retention"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/server"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
 	"github.com/Sphirium/wb-tech-demo-lo/pkg/logger"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
@@ -24,43 +38,327 @@ func main() {
 	cfg := config.Load()
 	logg := logger.New(cfg.LogLevel)
 
+	// Шифрование персональных данных в БД и кеше
+	keyring, err := pii.OpenKeyring(cfg.PIIKeyfile, cfg.PIIEncryptionKey)
+	if err != nil {
+		log.Fatal("Failed to load PII keys: ", err)
+	}
+	pii.SetKeyring(keyring)
+	defaultRole, err := pii.ParseRole(cfg.PIIDefaultRole)
+	if err != nil {
+		log.Fatal("Invalid PII_DEFAULT_ROLE: ", err)
+	}
+
 	// Подключение к БД
-	db, err := gorm.Open(postgres.Open(cfg.PostgresURL), &gorm.Config{})
+	dbOptions := database.Options{
+		MaxOpenConns:    cfg.DBMaxOpenConns,
+		MaxIdleConns:    cfg.DBMaxIdleConns,
+		ConnMaxLifetime: cfg.DBConnMaxLifetime,
+		ConnMaxIdleTime: cfg.DBConnMaxIdleTime,
+		QueryTimeout:    cfg.DBQueryTimeout,
+		ConnectRetries:  cfg.DBConnectRetries,
+		ConnectBackoff:  cfg.DBConnectBackoff,
+	}
+	db, err := database.Open(cfg.PostgresURL, dbOptions)
+	if err != nil {
+		log.Fatal("Failed to connect to database: ", err)
+	}
+	// Миграции и фоновое обслуживание (секции, архив) переносят целые таблицы,
+	// поэтому идут через отдельный небольшой пул без таймаута запросов
+	maintenanceOptions := dbOptions
+	maintenanceOptions.QueryTimeout = 0
+	maintenanceOptions.MaxOpenConns, maintenanceOptions.MaxIdleConns = 2, 1
+	maintenanceDB, err := database.Open(cfg.PostgresURL, maintenanceOptions)
 	if err != nil {
 		log.Fatal("Failed to connect to database: ", err)
 	}
 
 	// Применяем миграции
-	if err := applyMigrations(db, "./migrations"); err != nil {
+	if err := applyMigrations(maintenanceDB, "./migrations"); err != nil {
 		log.Fatal("Migration failed: ", err)
 	}
 
+	// Секции orders/items
+	partitions := partition.NewMaintainer(repository.NewPartitionRepository(maintenanceDB), cfg.PartitionMonthsAhead, cfg.PartitionInterval)
+	partitions.Start()
+	defer partitions.Close()
+
+	// Реплики для чтения
+	// Подготовленные выражения включаем только для репозиториев заказов:
+	// многооператорные миграции и DDL секций их не поддерживают
+	prepared := &gorm.Session{PrepareStmt: cfg.DBPrepareStmt}
+	replicaOptions := dbOptions
+	replicaOptions.Lazy = true
+	var replicas []*gorm.DB
+	for _, dsn := range cfg.PostgresReplicaURLs {
+		replica, err := database.Open(dsn, replicaOptions)
+		if err != nil {
+			logg.Warn("Failed to open replica: %v", err)
+			continue
+		}
+		replicas = append(replicas, replica.Session(prepared))
+	}
+	router := repository.NewDBRouter(db.Session(prepared), replicas, repository.RouterOptions{
+		MaxLag:        cfg.ReplicaMaxLag,
+		StickyWindow:  cfg.ReplicaStickyWindow,
+		CheckInterval: cfg.ReplicaCheckInterval,
+	})
+	router.Start()
+	defer router.Close()
+
 	// Создаём зависимости
-	repo := repository.NewOrderRepository(db)
-	cache := cache.NewOrderCache(cfg.RedisAddr, cfg.RedisPassword)
-	serv := service.NewOrderService(repo, cache)
+	dbBreaker := breaker.New(cfg.DBBreakerFailures, cfg.DBBreakerCooldown, repository.IsInfrastructureError)
+	repo := repository.NewBreakerOrderRepository(repository.NewReplicatedOrderRepository(router, cfg.ReportingCurrency), dbBreaker)
+	orderCache := cache.NewOrderCache(cfg.RedisAddr, cfg.RedisPassword, keyring)
+	serv := service.NewOrderService(repo, orderCache)
+
+	// Деградированный режим: при недоступной БД чтения идут из кеша, приём на паузе
+	ingestGate := consumer.NewGate(cfg.DBProbeInterval)
+	defer ingestGate.Close()
+	monitor := health.NewMonitor(dbBreaker, func() error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.DBQueryTimeout)
+		defer cancel()
+		return sqlDB.PingContext(ctx)
+	}, ingestGate, cfg.DBProbeInterval)
+	serv.SetHealth(monitor)
+	monitor.Start()
+	defer monitor.Close()
+
+	// Пересчёт сумм оплаты в валюту отчётности
+	if cfg.FXRatesFile != "" {
+		rates, err := money.LoadRates(cfg.FXRatesFile)
+		if err != nil {
+			log.Fatal("Failed to load exchange rates: ", err)
+		}
+		if _, err := money.LookupCurrency(cfg.ReportingCurrency); err != nil {
+			log.Fatal("Invalid REPORTING_CURRENCY: ", err)
+		}
+		serv.SetRates(rates, cfg.ReportingCurrency)
+	}
+
+	// Архив старых заказов
+	archiver := retention.NewArchiver(repository.NewArchiveRepository(db, maintenanceDB), orderCache, retention.Policy{
+		HotDays:  cfg.RetentionHotDays,
+		Storage:  cfg.RetentionStorage,
+		Dir:      cfg.RetentionDir,
+		Interval: cfg.RetentionInterval,
+	})
+	serv.SetArchive(archiver)
+	archiver.Start()
+	defer archiver.Close()
 
 	// Восстанавливаем кеш
 	if err := serv.RestoreCacheFromDB(); err != nil {
 		logg.Warn("Failed to restore cache from DB: %v", err)
 	}
 
-	// Kafka consumer
-	consumer := consumer.NewKafkaConsumer(cfg.KafkaBroker, cfg.KafkaTopic, serv)
-	consumer.Start()
-	defer consumer.Close()
+	// Публикация событий из outbox
+	if cfg.OutboxTopic != "" {
+		relay := outbox.NewRelay(
+			repository.NewOutboxRepository(db),
+			outbox.NewKafkaPublisher(cfg.KafkaBroker, cfg.OutboxTopic),
+			cfg.OutboxInterval,
+			cfg.OutboxRetention,
+		)
+		relay.Start()
+		defer relay.Close()
+	}
+
+	// Источники заказов
+	sources := consumer.NewGroup(buildSources(cfg, logg)...)
+	sources.Start(ingestGate.Wrap(serv.SaveOrder))
+	defer sources.Close()
+
+	// Смена статусов заказов
+	if cfg.HasSource("kafka") && cfg.KafkaStatusTopic != "" {
+		statuses := consumer.NewKafkaConsumer(cfg.KafkaBroker, cfg.KafkaStatusTopic, "order-status-group")
+		statuses.Start(ingestGate.Wrap(serv.ApplyStatusMessage))
+		defer statuses.Close()
+	}
 
 	// HTTP
 	r := chi.NewRouter()
+	if cfg.TrustProxy {
+		r.Use(middleware.RealIP)
+	}
 	r.Use(middleware.Logger)
-	handler := handler.NewOrderHandler(serv)
-	r.Get("/order/{order_uid}", handler.GetOrder)
+	tlsEnabled := cfg.TLSCertFile != "" || cfg.TLSKeyFile != ""
+	r.Use(handler.SecurityHeaders(tlsEnabled))
+	r.Use(handler.CORS(handler.CORSOptions{AllowedOrigins: cfg.CORSAllowedOrigins, MaxAge: 600}))
+	if cfg.CompressionLevel > 0 {
+		r.Use(handler.Compress(cfg.CompressionLevel))
+	}
+	r.Use(handler.BodyLimit(int64(cfg.HTTPMaxBodyBytes)))
+	// Права вызывающего определяют доступные маршруты, роль — видимые персональные данные
+	authenticator, anonymousScopes, err := buildAuthenticator(cfg)
+	if err != nil {
+		log.Fatal("Failed to configure authentication: ", err)
+	}
+	r.Use(handler.Authenticate(authenticator, anonymousScopes, defaultRole))
+	// Лимиты частоты по классам маршрутов для каждого клиента
+	limiter, closeLimiter, err := buildLimiter(cfg)
+	if err != nil {
+		log.Fatal("Failed to configure rate limits: ", err)
+	}
+	defer closeLimiter()
+	cachePolicies, err := handler.ParseCacheControl(cfg.HTTPCacheControl)
+	if err != nil {
+		log.Fatal("Invalid HTTP_CACHE_CONTROL: ", err)
+	}
+	// Класс маршрутов задаёт лимит частоты и Cache-Control
+	routeClass := func(class string) func(http.Handler) http.Handler {
+		rateLimit, cacheControl := handler.RateLimit(limiter, class), handler.CacheControl(cachePolicies, class)
+		return func(next http.Handler) http.Handler {
+			return rateLimit(cacheControl(next))
+		}
+	}
+	healthHandler := handler.NewHealthHandler(monitor)
+	r.Get("/healthz", healthHandler.Live)
+	r.Get("/readyz", healthHandler.Ready)
+	orderHandler := handler.NewOrderHandler(serv)
+	var ingestHandler *handler.IngestHandler
+	if cfg.HasSource("http") {
+		idempotency := cache.NewIdempotencyStore(cfg.RedisAddr, cfg.RedisPassword, cfg.HTTPWriteTimeout)
+		defer idempotency.Close()
+		ingestHandler = handler.NewIngestHandler(serv, idempotency)
+	}
+	// Заказы отдаются в представлении API v1; пути без версии — синонимы /v1
+	orderRoutes := func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(handler.RequireScope(auth.ScopeRead))
+			read := r.With(routeClass("read"))
+			read.Get("/order/{order_uid}", orderHandler.GetOrder)
+			read.Get("/order/{order_uid}/history", orderHandler.GetOrderHistory)
+			read.Get("/order/by-track/{track}", orderHandler.GetOrderByTrack)
+			read.Get("/order/by-transaction/{tx}", orderHandler.GetOrderByTransaction)
+			// Списки и поиск дороже: у них свой, более строгий лимит
+			search := r.With(routeClass("search"))
+			search.Get("/customers/{id}/orders", orderHandler.GetCustomerOrders)
+			search.Get("/orders/search", orderHandler.SearchOrders)
+			search.Post("/orders:batchGet", orderHandler.BatchGetOrders)
+		})
+		if ingestHandler != nil {
+			r.Group(func(r chi.Router) {
+				r.Use(handler.RequireScope(auth.ScopeWrite), routeClass("write"))
+				r.Post("/orders", ingestHandler.CreateOrder)
+				r.Post("/orders:batch", ingestHandler.CreateOrdersBatch)
+			})
+		}
+	}
+	r.Route("/v1", orderRoutes)
+	r.Group(orderRoutes)
+	statsCache := cache.NewStatsCache(cfg.RedisAddr, cfg.RedisPassword, cfg.StatsCacheTTL)
+	defer statsCache.Close()
+	statsHandler := handler.NewStatsHandler(service.NewStatsService(repository.NewStatsRepository(router, cfg.ReportingCurrency), statsCache))
+	r.Group(func(r chi.Router) {
+		r.Use(handler.RequireScope(auth.ScopeRead), routeClass("stats"))
+		r.Get("/stats", statsHandler.Summary)
+		r.Get("/stats/{dimension}", statsHandler.Aggregate)
+	})
+	adminHandler := handler.NewAdminHandler(serv)
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(handler.RequireScope(auth.ScopeAdmin), routeClass("admin"))
+		r.Post("/orders/{order_uid}/cancel", adminHandler.CancelOrder)
+		r.Delete("/orders/{order_uid}", adminHandler.DeleteOrder)
+		r.Post("/orders/{order_uid}/purge", adminHandler.PurgeOrder)
+	})
+	// Счётчики expvar: ограничение частоты (ratelimit) и т.п.
+	r.With(handler.RequireScope(auth.ScopeAdmin)).Handle("/debug/vars", expvar.Handler())
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "./web/index.html")
 	})
 
-	logg.Info("Server starting on port %s", cfg.HTTPPort)
-	log.Fatal(http.ListenAndServe(":"+cfg.HTTPPort, r))
+	srv, err := server.New(r, server.Options{
+		Addr:               ":" + cfg.HTTPPort,
+		ReadHeaderTimeout:  cfg.HTTPReadHeaderTimeout,
+		ReadTimeout:        cfg.HTTPReadTimeout,
+		WriteTimeout:       cfg.HTTPWriteTimeout,
+		IdleTimeout:        cfg.HTTPIdleTimeout,
+		MaxHeaderBytes:     cfg.HTTPMaxHeaderBytes,
+		TLSCertFile:        cfg.TLSCertFile,
+		TLSKeyFile:         cfg.TLSKeyFile,
+		CertReloadInterval: cfg.TLSReloadInterval,
+		H2C:                cfg.HTTPH2C,
+	})
+	if err != nil {
+		log.Fatal("Failed to configure HTTP server: ", err)
+	}
+
+	// SIGINT/SIGTERM: дожидаемся текущих запросов и закрываем зависимости (defer выше)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logg.Info("Server starting on port %s (TLS: %t)", cfg.HTTPPort, srv.TLS())
+	if err := srv.Run(ctx, cfg.HTTPShutdownTimeout); err != nil {
+		log.Fatal("HTTP server failed: ", err)
+	}
+	logg.Info("Server stopped")
+}
+
+// buildAuthenticator собирает API-ключи и проверку JWT из конфига и права анонимных запросов
+func buildAuthenticator(cfg *config.Config) (*auth.Authenticator, []auth.Scope, error) {
+	var keys []auth.APIKey
+	for _, entry := range cfg.APIKeys {
+		key, err := auth.ParseAPIKey(entry)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	var verifier *auth.JWTVerifier
+	if cfg.JWKSFile != "" {
+		jwks, err := auth.LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		verifier = auth.NewJWTVerifier(jwks, cfg.JWTIssuer, cfg.JWTAudience)
+	}
+
+	anonymous, err := auth.ParseScopes(cfg.AnonymousScopes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("AUTH_ANONYMOUS_SCOPES: %w", err)
+	}
+	return auth.NewAuthenticator(keys, verifier), anonymous, nil
+}
+
+// buildLimiter создаёт ограничитель частоты с вёдрами в памяти или в Redis
+func buildLimiter(cfg *config.Config) (*ratelimit.Limiter, func() error, error) {
+	limits, err := ratelimit.ParseLimits(cfg.RateLimits)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.RateLimitRedis {
+		store := ratelimit.NewRedisStore(cfg.RedisAddr, cfg.RedisPassword)
+		return ratelimit.New(store, limits), store.Close, nil
+	}
+	return ratelimit.New(ratelimit.NewMemoryStore(), limits), func() error { return nil }, nil
+}
+
+// buildSources создаёт включённые в конфиге источники заказов
+func buildSources(cfg *config.Config, logg *logger.Logger) []consumer.OrderSource {
+	var sources []consumer.OrderSource
+	for _, name := range cfg.IngestSources {
+		switch name {
+		case "kafka":
+			sources = append(sources, consumer.NewKafkaConsumer(cfg.KafkaBroker, cfg.KafkaTopic, "order-group"))
+		case "http":
+			// HTTP-приём обслуживает IngestHandler, отдельный источник не нужен
+			continue
+		case "file":
+			if cfg.IngestFile == "" {
+				logg.Warn("INGEST_FILE is empty, file source skipped")
+				continue
+			}
+			sources = append(sources, consumer.NewFileSource(cfg.IngestFile))
+		default:
+			logg.Warn("Unknown ingest source %q skipped", name)
+		}
+	}
+	return sources
 }
 
 // applyMigrations выполняет все .up.sql миграции из папки
@@ -0,0 +1,178 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Ассоциации заказа, которые можно запросить через include
+const (
+	AssocDelivery = "delivery"
+	AssocPayment  = "payment"
+	AssocItems    = "items"
+)
+
+var associations = []string{AssocDelivery, AssocPayment, AssocItems}
+
+// Допустимые поля: верхнего уровня и поля каждой ассоциации — по JSON-тегам представления
+var (
+	orderFields = jsonFields(Order{})
+	assocFields = map[string]map[string]bool{
+		AssocDelivery: jsonFields(Delivery{}),
+		AssocPayment:  jsonFields(Payment{}),
+		AssocItems:    jsonFields(Item{}),
+	}
+)
+
+func jsonFields(v any) map[string]bool {
+	fields := map[string]bool{}
+	t := reflect.TypeOf(v)
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields[name] = true
+	}
+	return fields
+}
+
+// Fieldset — выборка полей ответа с заказом (?fields= и ?include=)
+type Fieldset struct {
+	// scalars — все поля верхнего уровня, кроме ассоциаций (задан только include)
+	scalars bool
+	// fields — поле верхнего уровня → поля ассоциации; nil — поле целиком
+	fields map[string]map[string]bool
+}
+
+// ParseFieldset разбирает выборку: fields — пути через запятую ("order_uid,payment.amount,
+// items.name"), include — ассоциации через запятую ("items,payment"). Ассоциации из include
+// попадают в ответ целиком, если fields не сужает их. nil — полное представление.
+func ParseFieldset(fields, include string) (*Fieldset, error) {
+	if strings.TrimSpace(fields) == "" && strings.TrimSpace(include) == "" {
+		return nil, nil
+	}
+
+	fs := &Fieldset{scalars: strings.TrimSpace(fields) == "", fields: map[string]map[string]bool{}}
+	for _, path := range splitList(fields) {
+		name, sub, nested := strings.Cut(path, ".")
+		if !orderFields[name] {
+			return nil, fmt.Errorf("unknown field %q", path)
+		}
+		if !nested {
+			fs.fields[name] = nil
+			continue
+		}
+		allowed, ok := assocFields[name]
+		if !ok || !allowed[sub] {
+			return nil, fmt.Errorf("unknown field %q", path)
+		}
+		subs, seen := fs.fields[name]
+		if seen && subs == nil {
+			continue // ассоциация уже запрошена целиком
+		}
+		if subs == nil {
+			subs = map[string]bool{}
+			fs.fields[name] = subs
+		}
+		subs[sub] = true
+	}
+	for _, assoc := range splitList(include) {
+		if !slices.Contains(associations, assoc) {
+			return nil, fmt.Errorf("unknown association %q", assoc)
+		}
+		if _, ok := fs.fields[assoc]; !ok {
+			fs.fields[assoc] = nil
+		}
+	}
+	return fs, nil
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Includes сообщает, нужна ли ассоциация assoc; для nil — нужны все
+func (fs *Fieldset) Includes(assoc string) bool {
+	if fs == nil {
+		return true
+	}
+	_, ok := fs.fields[assoc]
+	return ok
+}
+
+// Apply оставляет в представлении только выбранные поля. Результат сериализуется
+// в JSON с ключами по алфавиту.
+func (fs *Fieldset) Apply(dto *Order) (map[string]json.RawMessage, error) {
+	all, err := toFields(dto)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]json.RawMessage{}
+	for name, value := range all {
+		subs, selected := fs.fields[name]
+		if !selected {
+			if fs.scalars && !slices.Contains(associations, name) {
+				out[name] = value
+			}
+			continue
+		}
+		if subs == nil {
+			out[name] = value
+			continue
+		}
+		if out[name], err = pick(value, subs); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// pick оставляет поля subs в объекте или в каждом объекте массива; null остаётся null
+func pick(value json.RawMessage, subs map[string]bool) (json.RawMessage, error) {
+	if strings.HasPrefix(strings.TrimSpace(string(value)), "[") {
+		var list []map[string]json.RawMessage
+		if err := json.Unmarshal(value, &list); err != nil {
+			return nil, err
+		}
+		for i := range list {
+			list[i] = pickObject(list[i], subs)
+		}
+		return json.Marshal(list)
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(value, &obj); err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return value, nil
+	}
+	return json.Marshal(pickObject(obj, subs))
+}
+
+func pickObject(obj map[string]json.RawMessage, subs map[string]bool) map[string]json.RawMessage {
+	for name := range obj {
+		if !subs[name] {
+			delete(obj, name)
+		}
+	}
+	return obj
+}
+
+func toFields(v any) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
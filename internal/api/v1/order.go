@@ -0,0 +1,295 @@
+// Package v1 — публичное представление заказов в API версии 1.
+// Поля и их JSON-имена — контракт с клиентами: хранение может меняться,
+// а изменения здесь делаются только совместимыми (новые необязательные поля).
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/money"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+)
+
+type Order struct {
+	OrderUID          string           `json:"order_uid"`
+	TrackNumber       string           `json:"track_number"`
+	Entry             string           `json:"entry"`
+	Delivery          *Delivery        `json:"delivery"`
+	Payment           *Payment         `json:"payment"`
+	Items             []Item           `json:"items"`
+	Locale            string           `json:"locale"`
+	InternalSignature string           `json:"internal_signature"`
+	CustomerID        string           `json:"customer_id"`
+	DeliveryService   string           `json:"delivery_service"`
+	Shardkey          string           `json:"shardkey"`
+	SMID              int              `json:"sm_id"`
+	DateCreated       models.Timestamp `json:"date_created"`
+	OofShard          string           `json:"oof_shard"`
+	Status            string           `json:"status"`
+}
+
+type Delivery struct {
+	Name    string `json:"name"`
+	Phone   string `json:"phone"`
+	Zip     string `json:"zip"`
+	City    string `json:"city"`
+	Address string `json:"address"`
+	Region  string `json:"region"`
+	Email   string `json:"email"`
+}
+
+type Payment struct {
+	Transaction  string          `json:"transaction"`
+	RequestID    string          `json:"request_id"`
+	Currency     string          `json:"currency"`
+	Provider     string          `json:"provider"`
+	Amount       int             `json:"amount"`
+	PaymentDt    models.UnixTime `json:"payment_dt"`
+	Bank         string          `json:"bank"`
+	DeliveryCost int             `json:"delivery_cost"`
+	GoodsTotal   int             `json:"goods_total"`
+	CustomFee    int             `json:"custom_fee"`
+	Normalized   *Normalized     `json:"normalized,omitempty"`
+}
+
+// Normalized — суммы оплаты в валюте отчётности по курсу на RateDate
+type Normalized struct {
+	RateDate     string      `json:"rate_date"`
+	Amount       money.Money `json:"amount"`
+	DeliveryCost money.Money `json:"delivery_cost"`
+	GoodsTotal   money.Money `json:"goods_total"`
+	CustomFee    money.Money `json:"custom_fee"`
+}
+
+type Item struct {
+	ChrtID      int64  `json:"chrt_id"`
+	TrackNumber string `json:"track_number"`
+	Price       int    `json:"price"`
+	RID         string `json:"rid"`
+	Name        string `json:"name"`
+	Sale        int    `json:"sale"`
+	Size        string `json:"size"`
+	TotalPrice  int    `json:"total_price"`
+	NMID        int64  `json:"nm_id"`
+	Brand       string `json:"brand"`
+	Status      int    `json:"status"`
+}
+
+// StatusChange — запись истории статусов заказа
+type StatusChange struct {
+	From      string           `json:"from,omitempty"`
+	To        string           `json:"to"`
+	Reason    string           `json:"reason,omitempty"`
+	ChangedAt models.Timestamp `json:"changed_at"`
+}
+
+// FromOrder строит представление заказа; nil — для nil
+func FromOrder(o *models.Order) *Order {
+	if o == nil {
+		return nil
+	}
+
+	dto := &Order{
+		OrderUID:          o.OrderUID,
+		TrackNumber:       o.TrackNumber,
+		Entry:             o.Entry,
+		Locale:            o.Locale,
+		InternalSignature: o.InternalSignature,
+		CustomerID:        o.CustomerID,
+		DeliveryService:   o.DeliveryService,
+		Shardkey:          o.Shardkey,
+		SMID:              o.SMID,
+		DateCreated:       o.DateCreated,
+		OofShard:          o.OofShard,
+		Status:            string(o.Status),
+		Items:             make([]Item, 0, len(o.Items)),
+	}
+	if d := o.Delivery; d != nil {
+		dto.Delivery = &Delivery{
+			Name:    d.Name,
+			Phone:   d.Phone,
+			Zip:     d.Zip,
+			City:    d.City,
+			Address: d.Address,
+			Region:  d.Region,
+			Email:   d.Email,
+		}
+	}
+	if p := o.Payment; p != nil {
+		dto.Payment = &Payment{
+			Transaction:  p.Transaction,
+			RequestID:    p.RequestID,
+			Currency:     p.Currency,
+			Provider:     p.Provider,
+			Amount:       p.Amount,
+			PaymentDt:    p.PaymentDt,
+			Bank:         p.Bank,
+			DeliveryCost: p.DeliveryCost,
+			GoodsTotal:   p.GoodsTotal,
+			CustomFee:    p.CustomFee,
+		}
+		if n := p.Normalized; n != nil {
+			dto.Payment.Normalized = &Normalized{
+				RateDate:     n.RateDate,
+				Amount:       n.Amount,
+				DeliveryCost: n.DeliveryCost,
+				GoodsTotal:   n.GoodsTotal,
+				CustomFee:    n.CustomFee,
+			}
+		}
+	}
+	for _, it := range o.Items {
+		dto.Items = append(dto.Items, Item{
+			ChrtID:      it.ChrtID,
+			TrackNumber: it.TrackNumber,
+			Price:       it.Price,
+			RID:         it.RID,
+			Name:        it.Name,
+			Sale:        it.Sale,
+			Size:        it.Size,
+			TotalPrice:  it.TotalPrice,
+			NMID:        it.NMID,
+			Brand:       it.Brand,
+			Status:      it.Status,
+		})
+	}
+	return dto
+}
+
+// Version возвращает версию представления заказа: хеш его JSON в API v1.
+// Меняется при любом изменении видимых в API полей.
+func Version(o *models.Order) string {
+	if o.Version != "" {
+		return o.Version
+	}
+	data, _ := json.Marshal(FromOrder(o))
+	return ContentVersion(data)
+}
+
+// ContentVersion — версия по уже сериализованному представлению заказа
+func ContentVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+// FromOrders строит представления списка заказов; пустой список — [], а не null
+func FromOrders(orders []models.Order) []Order {
+	dtos := make([]Order, 0, len(orders))
+	for i := range orders {
+		dtos = append(dtos, *FromOrder(&orders[i]))
+	}
+	return dtos
+}
+
+// Model восстанавливает доменный заказ из представления. Служебных полей
+// хранения (ID, CreatedAt, UpdatedAt) в представлении нет, они остаются нулевыми.
+func (dto *Order) Model() *models.Order {
+	o := &models.Order{
+		OrderUID:          dto.OrderUID,
+		TrackNumber:       dto.TrackNumber,
+		Entry:             dto.Entry,
+		Locale:            dto.Locale,
+		InternalSignature: dto.InternalSignature,
+		CustomerID:        dto.CustomerID,
+		DeliveryService:   dto.DeliveryService,
+		Shardkey:          dto.Shardkey,
+		SMID:              dto.SMID,
+		DateCreated:       dto.DateCreated,
+		OofShard:          dto.OofShard,
+		Status:            models.OrderStatus(dto.Status),
+	}
+	if d := dto.Delivery; d != nil {
+		o.Delivery = &models.Delivery{
+			OrderID: dto.OrderUID,
+			Name:    d.Name,
+			Phone:   d.Phone,
+			Zip:     d.Zip,
+			City:    d.City,
+			Address: d.Address,
+			Region:  d.Region,
+			Email:   d.Email,
+		}
+	}
+	if p := dto.Payment; p != nil {
+		o.Payment = &models.Payment{
+			Transaction:  p.Transaction,
+			OrderID:      dto.OrderUID,
+			RequestID:    p.RequestID,
+			Currency:     p.Currency,
+			Provider:     p.Provider,
+			Amount:       p.Amount,
+			PaymentDt:    p.PaymentDt,
+			Bank:         p.Bank,
+			DeliveryCost: p.DeliveryCost,
+			GoodsTotal:   p.GoodsTotal,
+			CustomFee:    p.CustomFee,
+		}
+		if n := p.Normalized; n != nil {
+			o.Payment.Normalized = &models.NormalizedPayment{
+				RateDate:     n.RateDate,
+				Amount:       n.Amount,
+				DeliveryCost: n.DeliveryCost,
+				GoodsTotal:   n.GoodsTotal,
+				CustomFee:    n.CustomFee,
+			}
+		}
+	}
+	for _, it := range dto.Items {
+		o.Items = append(o.Items, models.Item{
+			ChrtID:      it.ChrtID,
+			OrderID:     dto.OrderUID,
+			TrackNumber: it.TrackNumber,
+			Price:       it.Price,
+			RID:         it.RID,
+			Name:        it.Name,
+			Sale:        it.Sale,
+			Size:        it.Size,
+			TotalPrice:  it.TotalPrice,
+			NMID:        it.NMID,
+			Brand:       it.Brand,
+			Status:      it.Status,
+			DateCreated: dto.DateCreated,
+		})
+	}
+	return o
+}
+
+// FromHistory строит представление истории статусов
+func FromHistory(history []models.StatusHistory) []StatusChange {
+	dtos := make([]StatusChange, 0, len(history))
+	for _, h := range history {
+		dtos = append(dtos, StatusChange{
+			From:      string(h.FromStatus),
+			To:        string(h.ToStatus),
+			Reason:    h.Reason,
+			ChangedAt: models.NewTimestamp(h.ChangedAt),
+		})
+	}
+	return dtos
+}
+
+// Redact маскирует поля заказа по политике роли вызывающего
+func (dto *Order) Redact(policy pii.Policy) {
+	if len(policy) == 0 {
+		return
+	}
+	dto.InternalSignature = policy.Apply("internal_signature", dto.InternalSignature)
+	if d := dto.Delivery; d != nil {
+		d.Name = policy.Apply("delivery.name", d.Name)
+		d.Phone = policy.Apply("delivery.phone", d.Phone)
+		d.Zip = policy.Apply("delivery.zip", d.Zip)
+		d.City = policy.Apply("delivery.city", d.City)
+		d.Address = policy.Apply("delivery.address", d.Address)
+		d.Region = policy.Apply("delivery.region", d.Region)
+		d.Email = policy.Apply("delivery.email", d.Email)
+	}
+	if p := dto.Payment; p != nil {
+		p.Transaction = policy.Apply("payment.transaction", p.Transaction)
+		p.RequestID = policy.Apply("payment.request_id", p.RequestID)
+		p.Provider = policy.Apply("payment.provider", p.Provider)
+		p.Bank = policy.Apply("payment.bank", p.Bank)
+	}
+}
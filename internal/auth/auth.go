@@ -0,0 +1,162 @@
+// Package auth — аутентификация запросов к API: статические API-ключи
+// (в конфиге хранятся только их SHA-256) и JWT, проверяемые по локальному JWKS.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+)
+
+// Scope — право на группу маршрутов
+type Scope string
+
+const (
+	// ScopeRead — чтение заказов, поиск и статистика
+	ScopeRead Scope = "orders:read"
+	// ScopeWrite — приём заказов через HTTP
+	ScopeWrite Scope = "orders:write"
+	// ScopeAdmin — административные операции; включает все остальные права
+	// и открывает персональные данные полностью
+	ScopeAdmin Scope = "orders:admin"
+	// ScopePII — персональные данные с маскированием как для поддержки
+	ScopePII Scope = "orders:pii"
+)
+
+var knownScopes = map[Scope]bool{ScopeRead: true, ScopeWrite: true, ScopeAdmin: true, ScopePII: true}
+
+// ErrUnauthorized — учётные данные предъявлены, но не прошли проверку
+var ErrUnauthorized = errors.New("invalid credentials")
+
+// ParseScopes разбирает список прав через пробел или запятую
+func ParseScopes(s string) ([]Scope, error) {
+	var scopes []Scope
+	for _, name := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' }) {
+		scope := Scope(name)
+		if !knownScopes[scope] {
+			return nil, fmt.Errorf("unknown scope %q", name)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// Principal — вызывающий и его права
+type Principal struct {
+	// Subject — имя API-ключа или sub из JWT; пустой у анонимного вызывающего
+	Subject string
+	Scopes  []Scope
+}
+
+// Anonymous сообщает, что запрос пришёл без учётных данных
+func (p *Principal) Anonymous() bool {
+	return p.Subject == ""
+}
+
+// Has проверяет право; orders:admin включает все права
+func (p *Principal) Has(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Role — какие персональные данные видит вызывающий; без orders:admin и
+// orders:pii — fallback
+func (p *Principal) Role(fallback pii.Role) pii.Role {
+	switch {
+	case p.Has(ScopeAdmin):
+		return pii.RoleAdmin
+	case p.Has(ScopePII):
+		return pii.RoleSupport
+	}
+	return fallback
+}
+
+type principalKey struct{}
+
+// WithPrincipal сохраняет вызывающего в контексте запроса
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom возвращает вызывающего из контекста; без него — анонимный без прав
+func PrincipalFrom(ctx context.Context) *Principal {
+	if p, ok := ctx.Value(principalKey{}).(*Principal); ok {
+		return p
+	}
+	return &Principal{}
+}
+
+// APIKey — статический ключ; сам ключ в конфиге не хранится, только SHA-256
+type APIKey struct {
+	Name   string
+	Hash   [sha256.Size]byte
+	Scopes []Scope
+}
+
+// ParseAPIKey разбирает запись конфига "<имя>:<sha256 hex>:<права через пробел>",
+// например "ci:9f86d0...:orders:read orders:write"
+func ParseAPIKey(entry string) (APIKey, error) {
+	parts := strings.SplitN(entry, ":", 3)
+	if len(parts) != 3 || parts[0] == "" {
+		return APIKey{}, fmt.Errorf("API key %q: want <name>:<sha256 hex>:<scopes>", entry)
+	}
+	key := APIKey{Name: parts[0]}
+	hash, err := hex.DecodeString(parts[1])
+	if err != nil || len(hash) != sha256.Size {
+		return APIKey{}, fmt.Errorf("API key %q: hash must be hex SHA-256", key.Name)
+	}
+	copy(key.Hash[:], hash)
+	if key.Scopes, err = ParseScopes(parts[2]); err != nil {
+		return APIKey{}, fmt.Errorf("API key %q: %w", key.Name, err)
+	}
+	return key, nil
+}
+
+// NewAPIKey строит ключ по открытому значению; в конфиге ключи задаются только хешем (ParseAPIKey)
+func NewAPIKey(name, secret string, scopes ...Scope) APIKey {
+	return APIKey{Name: name, Hash: sha256.Sum256([]byte(secret)), Scopes: scopes}
+}
+
+// Authenticator проверяет учётные данные из запроса
+type Authenticator struct {
+	keys []APIKey
+	jwt  *JWTVerifier
+}
+
+// NewAuthenticator; jwt == nil — JWT не принимаются
+func NewAuthenticator(keys []APIKey, jwt *JWTVerifier) *Authenticator {
+	return &Authenticator{keys: keys, jwt: jwt}
+}
+
+// Authenticate проверяет токен: строка из трёх частей через точку — JWT, иначе API-ключ
+func (a *Authenticator) Authenticate(token string) (*Principal, error) {
+	if strings.Count(token, ".") == 2 {
+		if a.jwt == nil {
+			return nil, ErrUnauthorized
+		}
+		return a.jwt.Verify(token)
+	}
+
+	hash := sha256.Sum256([]byte(token))
+	var found *APIKey
+	// Сравниваются все ключи, чтобы время ответа не зависело от того, какой совпал
+	for i := range a.keys {
+		if subtle.ConstantTimeCompare(hash[:], a.keys[i].Hash[:]) == 1 {
+			found = &a.keys[i]
+		}
+	}
+	if found == nil {
+		return nil, ErrUnauthorized
+	}
+	return &Principal{Subject: found.Name, Scopes: found.Scopes}, nil
+}
@@ -0,0 +1,356 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// Допустимое расхождение часов с издателем токенов
+const clockSkew = time.Minute
+
+// JWKS — открытые ключи издателя токенов по kid
+type JWKS struct {
+	keys map[string]jwk
+}
+
+type jwk struct {
+	alg string // пустой — любой алгоритм, подходящий по типу ключа
+	key crypto.PublicKey
+}
+
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// LoadJWKS читает JWKS из файла
+func LoadJWKS(path string) (*JWKS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseJWKS(f)
+}
+
+// ParseJWKS разбирает JWKS ({"keys": [...]}); поддерживаются ключи RSA, EC P-256/P-384 и Ed25519
+func ParseJWKS(r io.Reader) (*JWKS, error) {
+	var set struct {
+		Keys []rawJWK `json:"keys"`
+	}
+	if err := json.NewDecoder(r).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode JWKS: %w", err)
+	}
+
+	jwks := &JWKS{keys: make(map[string]jwk, len(set.Keys))}
+	for _, raw := range set.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := raw.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %q: %w", raw.Kid, err)
+		}
+		jwks.keys[raw.Kid] = jwk{alg: raw.Alg, key: key}
+	}
+	if len(jwks.keys) == 0 {
+		return nil, fmt.Errorf("JWKS has no signing keys")
+	}
+	return jwks, nil
+}
+
+func (raw rawJWK) publicKey() (crypto.PublicKey, error) {
+	switch raw.Kty {
+	case "RSA":
+		n, err := decodeBigInt(raw.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(raw.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch raw.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", raw.Crv)
+		}
+		x, err := decodeBigInt(raw.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(raw.Y)
+		if err != nil {
+			return nil, err
+		}
+		// Проверка, что точка лежит на кривой
+		if _, err := (&ecdsa.PublicKey{Curve: curve, X: x, Y: y}).ECDH(); err != nil {
+			return nil, fmt.Errorf("invalid EC key: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if raw.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", raw.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(raw.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", raw.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// JWTVerifier проверяет подпись и срок действия JWT, а также iss и aud, если они заданы
+type JWTVerifier struct {
+	jwks     *JWKS
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+func NewJWTVerifier(jwks *JWKS, issuer, audience string) *JWTVerifier {
+	return &JWTVerifier{jwks: jwks, issuer: issuer, audience: audience, now: time.Now}
+}
+
+// WithClock подменяет часы (для тестов)
+func (v *JWTVerifier) WithClock(now func() time.Time) *JWTVerifier {
+	v.now = now
+	return v
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string     `json:"sub"`
+	Issuer    string     `json:"iss"`
+	Audience  stringList `json:"aud"`
+	ExpiresAt *float64   `json:"exp"`
+	NotBefore *float64   `json:"nbf"`
+	Scope     string     `json:"scope"`
+	Scp       stringList `json:"scp"`
+}
+
+// stringList — строка или массив строк (aud, scp)
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*l = strings.Fields(one)
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*l = many
+	return nil
+}
+
+// Verify проверяет токен и возвращает вызывающего с правами из scope (или scp).
+// Неизвестные права игнорируются: токен может быть выпущен и для других сервисов.
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrUnauthorized
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	key, err := v.key(header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrUnauthorized, err)
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	if err := v.validate(claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+
+	names := append(strings.Fields(claims.Scope), claims.Scp...)
+	p := &Principal{Subject: claims.Subject}
+	for _, name := range names {
+		if knownScopes[Scope(name)] {
+			p.Scopes = append(p.Scopes, Scope(name))
+		}
+	}
+	return p, nil
+}
+
+func (v *JWTVerifier) key(header jwtHeader) (crypto.PublicKey, error) {
+	k, ok := v.jwks.keys[header.Kid]
+	if !ok && header.Kid == "" && len(v.jwks.keys) == 1 {
+		for _, only := range v.jwks.keys {
+			k, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", header.Kid)
+	}
+	if k.alg != "" && k.alg != header.Alg {
+		return nil, fmt.Errorf("alg %q does not match key", header.Alg)
+	}
+	return k.key, nil
+}
+
+func (v *JWTVerifier) validate(c jwtClaims) error {
+	now := v.now()
+	if c.Subject == "" {
+		return fmt.Errorf("sub is required")
+	}
+	if c.ExpiresAt == nil {
+		return fmt.Errorf("exp is required")
+	}
+	if now.After(unixTime(*c.ExpiresAt).Add(clockSkew)) {
+		return fmt.Errorf("token expired")
+	}
+	if c.NotBefore != nil && now.Add(clockSkew).Before(unixTime(*c.NotBefore)) {
+		return fmt.Errorf("token not valid yet")
+	}
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return fmt.Errorf("unexpected iss %q", c.Issuer)
+	}
+	if v.audience != "" {
+		for _, aud := range c.Audience {
+			if aud == v.audience {
+				return nil
+			}
+		}
+		return fmt.Errorf("token is not issued for %q", v.audience)
+	}
+	return nil
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// verifySignature проверяет подпись алгоритмом из заголовка; тип ключа должен
+// соответствовать алгоритму, иначе ключ RSA нельзя было бы подменить HMAC-секретом и т.п.
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	switch alg {
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("alg %s needs an RSA key", alg)
+		}
+		h, digest := hashFor(alg[2:])
+		if alg[0] == 'P' {
+			return rsa.VerifyPSS(pub, h, digest(signed), signature, nil)
+		}
+		return rsa.VerifyPKCS1v15(pub, h, digest(signed), signature)
+
+	case "ES256", "ES384":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("alg %s needs an EC key", alg)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if alg == "ES256" && size != 32 || alg == "ES384" && size != 48 {
+			return fmt.Errorf("alg %s does not match curve %s", alg, pub.Curve.Params().Name)
+		}
+		if len(signature) != 2*size {
+			return fmt.Errorf("invalid ECDSA signature length")
+		}
+		_, digest := hashFor(alg[2:])
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest(signed), r, s) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+
+	case "EdDSA":
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("alg %s needs an Ed25519 key", alg)
+		}
+		if !ed25519.Verify(pub, signed, signature) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported alg %q", alg)
+}
+
+func hashFor(bits string) (crypto.Hash, func([]byte) []byte) {
+	var newHash func() hash.Hash
+	h := crypto.SHA256
+	switch bits {
+	case "384":
+		h, newHash = crypto.SHA384, sha512.New384
+	case "512":
+		h, newHash = crypto.SHA512, sha512.New
+	default:
+		newHash = sha256.New
+	}
+	return h, func(data []byte) []byte {
+		d := newHash()
+		d.Write(data)
+		return d.Sum(nil)
+	}
+}
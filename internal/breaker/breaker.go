@@ -0,0 +1,132 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen — цепь разомкнута, вызов отклонён без обращения к зависимости
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State — состояние предохранителя
+type State int
+
+const (
+	Closed   State = iota // вызовы проходят
+	Open                  // вызовы отклоняются до истечения cooldown
+	HalfOpen              // пропускается один пробный вызов
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker размыкает цепь после threshold подряд идущих отказов и через
+// cooldown пропускает один пробный вызов
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	isFailure func(error) bool
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+	onChange func(State)
+}
+
+// New создаёт предохранитель; isFailure отличает отказ зависимости
+// от ожидаемых ошибок (не найдено, дубликат и т.п.)
+func New(threshold int, cooldown time.Duration, isFailure func(error) bool) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown, isFailure: isFailure}
+}
+
+// OnStateChange задаёт обработчик смены состояния
+func (b *Breaker) OnStateChange(fn func(State)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onChange = fn
+}
+
+// Do выполняет fn, если цепь замкнута, и учитывает результат
+func (b *Breaker) Do(fn func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.record(err != nil && b.isFailure(err))
+	return err
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *Breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrOpen
+		}
+		b.setState(HalfOpen)
+		b.probing = true
+		return nil
+	case HalfOpen:
+		if b.probing {
+			return ErrOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+func (b *Breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen {
+		b.probing = false
+		if failed {
+			b.openedAt = time.Now()
+			b.setState(Open)
+		} else {
+			b.failures = 0
+			b.setState(Closed)
+		}
+		return
+	}
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == Closed && b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(Open)
+	}
+}
+
+func (b *Breaker) setState(s State) {
+	if b.state == s {
+		return
+	}
+	b.state = s
+	if b.onChange != nil {
+		go b.onChange(s)
+	}
+}
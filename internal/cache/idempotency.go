@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// IdempotencyRecord — сохранённый ответ на запрос с Idempotency-Key
+type IdempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status"`
+	Body        []byte `json:"body,omitempty"`
+	Pending     bool   `json:"pending,omitempty"`
+}
+
+type IdempotencyStoreInterface interface {
+	// Reserve занимает ключ; если ключ уже занят, возвращает существующую запись
+	Reserve(key, fingerprint string) (*IdempotencyRecord, bool, error)
+	// Complete сохраняет итоговый ответ для ключа
+	Complete(key string, record *IdempotencyRecord) error
+	// Release освобождает ключ, если запрос не удалось выполнить
+	Release(key string) error
+	Close() error
+}
+
+type IdempotencyStore struct {
+	client     *redis.Client
+	ctx        context.Context
+	ttl        time.Duration // сколько хранится итоговый ответ
+	pendingTTL time.Duration // сколько держится резерв незавершённого запроса
+}
+
+// minPendingTTL — нижняя граница срока резерва: при pendingTTL = 0 (HTTP_WRITE_TIMEOUT
+// без ограничения) резерв без срока занял бы ключ навсегда
+const minPendingTTL = 30 * time.Second
+
+// NewIdempotencyStore: pendingTTL — не меньше максимальной длительности запроса,
+// чтобы резерв упавшего процесса не блокировал ключ надолго; не меньше minPendingTTL
+func NewIdempotencyStore(addr, password string, pendingTTL time.Duration) IdempotencyStoreInterface {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       0,
+	})
+
+	return &IdempotencyStore{
+		client:     client,
+		ctx:        context.Background(),
+		ttl:        24 * time.Hour,
+		pendingTTL: max(pendingTTL, minPendingTTL),
+	}
+}
+
+func (s *IdempotencyStore) Reserve(key, fingerprint string) (*IdempotencyRecord, bool, error) {
+	pending, err := json.Marshal(&IdempotencyRecord{Fingerprint: fingerprint, Pending: true})
+	if err != nil {
+		return nil, false, err
+	}
+
+	ok, err := s.client.SetNX(s.ctx, idempotencyKey(key), pending, s.pendingTTL).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		return nil, true, nil
+	}
+
+	data, err := s.client.Get(s.ctx, idempotencyKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// ключ успел истечь между SETNX и GET — пробуем ещё раз
+		return s.Reserve(key, fingerprint)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var record IdempotencyRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, false, err
+	}
+	return &record, false, nil
+}
+
+func (s *IdempotencyStore) Complete(key string, record *IdempotencyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(s.ctx, idempotencyKey(key), data, s.ttl).Err()
+}
+
+func (s *IdempotencyStore) Release(key string) error {
+	return s.client.Del(s.ctx, idempotencyKey(key)).Err()
+}
+
+func (s *IdempotencyStore) Close() error {
+	return s.client.Close()
+}
+
+func idempotencyKey(key string) string {
+	return "idempotency:" + key
+}
@@ -5,22 +5,44 @@ import (
 	"encoding/json"
 	"time"
 
+	v1 "github.com/Sphirium/wb-tech-demo-lo/internal/api/v1"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
 	"github.com/go-redis/redis/v8"
 )
 
+const (
+	orderTTL = 24 * time.Hour
+	// Список заказов клиента сбрасывается при создании заказа, TTL ограничивает
+	// устаревание после архивации и удаления
+	customerTTL = time.Hour
+)
+
 type OrderCacheInterface interface {
 	Get(orderUID string) (*models.Order, error)
+	// GetMany читает заказы одним MGET; отсутствующих в кеше нет в результате
+	GetMany(orderUIDs []string) (map[string]*models.Order, error)
+	// Set сохраняет заказ и вторичные ключи track_number и payment.transaction
 	Set(order *models.Order) error
+	// Delete удаляет заказ вместе с его вторичными ключами и списком заказов клиента
+	Delete(orderUID string) error
+	GetByTrackNumber(trackNumber string) (*models.Order, error)
+	GetByTransaction(transaction string) (*models.Order, error)
+	// GetCustomerOrderUIDs возвращает закешированный полный список заказов клиента
+	GetCustomerOrderUIDs(customerID string) ([]string, error)
+	SetCustomerOrderUIDs(customerID string, uids []string) error
+	DeleteCustomerOrderUIDs(customerID string) error
 	Close() error
 }
 
 type OrderCache struct {
 	client *redis.Client
 	ctx    context.Context
+	// keyring шифрует закешированные заказы целиком; nil — хранятся открытыми
+	keyring *pii.Keyring
 }
 
-func NewOrderCache(addr, password string) OrderCacheInterface {
+func NewOrderCache(addr, password string, keyring *pii.Keyring) OrderCacheInterface {
 	client := redis.NewClient(&redis.Options{
 		Addr:     addr,
 		Password: password,
@@ -28,30 +50,192 @@ func NewOrderCache(addr, password string) OrderCacheInterface {
 	})
 
 	return &OrderCache{
-		client: client,
-		ctx:    context.Background(),
+		client:  client,
+		ctx:     context.Background(),
+		keyring: keyring,
 	}
 }
 
+func orderKey(orderUID string) string {
+	return "order:" + orderUID
+}
+
+func trackKey(trackNumber string) string {
+	return "order:track:" + trackNumber
+}
+
+func transactionKey(transaction string) string {
+	return "order:tx:" + transaction
+}
+
+func customerKey(customerID string) string {
+	return "customer:" + customerID + ":orders"
+}
+
+// В кеше заказ хранится в представлении API v1: оно же отдаётся клиентам.
+// При настроенных ключах представление шифруется тем же конвертом, что и столбцы PII.
 func (c *OrderCache) Set(order *models.Order) error {
-	data, err := json.Marshal(order)
+	data, err := c.encodeOrder(order)
 	if err != nil {
 		return err
 	}
-	return c.client.Set(c.ctx, "order:"+order.OrderUID, data, 24*time.Hour).Err()
+
+	pipe := c.client.TxPipeline()
+	pipe.Set(c.ctx, orderKey(order.OrderUID), data, orderTTL)
+	if order.TrackNumber != "" {
+		pipe.Set(c.ctx, trackKey(order.TrackNumber), order.OrderUID, orderTTL)
+	}
+	if order.Payment != nil && order.Payment.Transaction != "" {
+		pipe.Set(c.ctx, transactionKey(order.Payment.Transaction), order.OrderUID, orderTTL)
+	}
+	_, err = pipe.Exec(c.ctx)
+	return err
 }
 
 func (c *OrderCache) Get(orderUID string) (*models.Order, error) {
-	data, err := c.client.Get(c.ctx, "order:"+orderUID).Result()
+	data, err := c.client.Get(c.ctx, orderKey(orderUID)).Result()
 	if err != nil {
 		return nil, err
 	}
 
-	var order models.Order
-	if err := json.Unmarshal([]byte(data), &order); err != nil {
+	return c.decodeOrder(data)
+}
+
+// orderEntry — запись заказа в кеше: представление API v1 с его версией (ETag)
+// и временем изменения (Last-Modified), которых в самом представлении нет
+type orderEntry struct {
+	Version   string          `json:"version"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Order     json.RawMessage `json:"order"`
+}
+
+func (c *OrderCache) encodeOrder(order *models.Order) (string, error) {
+	dto, err := json.Marshal(v1.FromOrder(order))
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(orderEntry{Version: v1.ContentVersion(dto), UpdatedAt: order.UpdatedAt, Order: dto})
+	if err != nil || c.keyring == nil {
+		return string(data), err
+	}
+	return c.keyring.Encrypt(string(data))
+}
+
+// decodeOrder читает заказ из кеша. Зашифрованная запись без ключей или под
+// неизвестным ключом возвращает ошибку и для вызывающего равна промаху.
+func (c *OrderCache) decodeOrder(data string) (*models.Order, error) {
+	if pii.IsEncrypted(data) {
+		if c.keyring == nil {
+			return nil, pii.ErrNoKey
+		}
+		var err error
+		if data, err = c.keyring.Decrypt(data); err != nil {
+			return nil, err
+		}
+	}
+
+	var entry orderEntry
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		return nil, err
+	}
+	if entry.Order == nil {
+		// Запись прежнего формата — представление без обёртки
+		entry.Order = json.RawMessage(data)
+		entry.Version = v1.ContentVersion(entry.Order)
+	}
+
+	var dto v1.Order
+	if err := json.Unmarshal(entry.Order, &dto); err != nil {
 		return nil, err
 	}
-	return &order, nil
+	order := dto.Model()
+	order.Version = entry.Version
+	order.UpdatedAt = entry.UpdatedAt
+	return order, nil
+}
+
+func (c *OrderCache) GetMany(orderUIDs []string) (map[string]*models.Order, error) {
+	orders := make(map[string]*models.Order, len(orderUIDs))
+	if len(orderUIDs) == 0 {
+		return orders, nil
+	}
+
+	keys := make([]string, len(orderUIDs))
+	for i, uid := range orderUIDs {
+		keys[i] = orderKey(uid)
+	}
+	values, err := c.client.MGet(c.ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue // промах
+		}
+		order, err := c.decodeOrder(data)
+		if err != nil {
+			continue
+		}
+		orders[orderUIDs[i]] = order
+	}
+	return orders, nil
+}
+
+func (c *OrderCache) GetByTrackNumber(trackNumber string) (*models.Order, error) {
+	return c.getBy(trackKey(trackNumber))
+}
+
+func (c *OrderCache) GetByTransaction(transaction string) (*models.Order, error) {
+	return c.getBy(transactionKey(transaction))
+}
+
+// getBy разрешает вторичный ключ в order_uid и читает заказ
+func (c *OrderCache) getBy(key string) (*models.Order, error) {
+	uid, err := c.client.Get(c.ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	return c.Get(uid)
+}
+
+func (c *OrderCache) Delete(orderUID string) error {
+	keys := []string{orderKey(orderUID)}
+	// Вторичные ключи известны только из самого заказа; если его уже нет в кеше,
+	// они истекут по TTL, а чтение по ним промахнётся мимо основного ключа
+	if order, err := c.Get(orderUID); err == nil {
+		keys = append(keys, trackKey(order.TrackNumber), customerKey(order.CustomerID))
+		if order.Payment != nil {
+			keys = append(keys, transactionKey(order.Payment.Transaction))
+		}
+	}
+	return c.client.Del(c.ctx, keys...).Err()
+}
+
+func (c *OrderCache) GetCustomerOrderUIDs(customerID string) ([]string, error) {
+	data, err := c.client.Get(c.ctx, customerKey(customerID)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []string
+	if err := json.Unmarshal(data, &uids); err != nil {
+		return nil, err
+	}
+	return uids, nil
+}
+
+func (c *OrderCache) SetCustomerOrderUIDs(customerID string, uids []string) error {
+	data, err := json.Marshal(uids)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(c.ctx, customerKey(customerID), data, customerTTL).Err()
+}
+
+func (c *OrderCache) DeleteCustomerOrderUIDs(customerID string) error {
+	return c.client.Del(c.ctx, customerKey(customerID)).Err()
 }
 
 func (c *OrderCache) Close() error {
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// StatsCacheInterface — кеш результатов агрегирующих запросов с коротким TTL
+type StatsCacheInterface interface {
+	// Get читает значение в dst; ok == false, если ключа нет
+	Get(key string, dst any) (ok bool, err error)
+	Set(key string, value any) error
+	Close() error
+}
+
+type StatsCache struct {
+	client *redis.Client
+	ctx    context.Context
+	ttl    time.Duration
+}
+
+func NewStatsCache(addr, password string, ttl time.Duration) StatsCacheInterface {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       0,
+	})
+
+	return &StatsCache{
+		client: client,
+		ctx:    context.Background(),
+		ttl:    ttl,
+	}
+}
+
+func (c *StatsCache) Get(key string, dst any) (bool, error) {
+	data, err := c.client.Get(c.ctx, "stats:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, dst)
+}
+
+func (c *StatsCache) Set(key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(c.ctx, "stats:"+key, data, c.ttl).Err()
+}
+
+func (c *StatsCache) Close() error {
+	return c.client.Close()
+}
@@ -1,6 +1,11 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Config struct {
 	HTTPPort      string
@@ -10,6 +15,102 @@ type Config struct {
 	RedisAddr     string
 	RedisPassword string
 	LogLevel      string
+
+	// HTTP-сервер: таймауты чтения заголовков, запроса, записи ответа, простоя
+	// соединения и ожидания текущих запросов при остановке; лимиты размера
+	// заголовков и тела запроса
+	HTTPReadHeaderTimeout time.Duration
+	HTTPReadTimeout       time.Duration
+	HTTPWriteTimeout      time.Duration
+	HTTPIdleTimeout       time.Duration
+	HTTPShutdownTimeout   time.Duration
+	HTTPMaxHeaderBytes    int
+	HTTPMaxBodyBytes      int
+	// TLS: сертификат и ключ (пустые — HTTP без TLS), как часто проверять их
+	// обновление на диске; HTTP/2 без TLS — только за своим прокси
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSReloadInterval time.Duration
+	HTTPH2C           bool
+	// Источники, с которых браузеру разрешено обращаться к API ("*" — любые),
+	// и уровень сжатия ответов gzip/brotli (1-11, 0 — без сжатия)
+	CORSAllowedOrigins []string
+	CompressionLevel   int
+	// Cache-Control по классам маршрутов (как в RATE_LIMITS): "<класс>=<директивы>" через ";"
+	HTTPCacheControl string
+
+	// Топик сообщений о смене статуса заказа; пустой — не читаем
+	KafkaStatusTopic string
+
+	// Источники заказов: kafka, http, file (через запятую)
+	IngestSources []string
+	// Путь к JSONL/JSON-файлу для источника file ("-" — stdin)
+	IngestFile string
+
+	// Топик для событий order.created/order.updated; пустой — relay выключен
+	OutboxTopic     string
+	OutboxInterval  time.Duration
+	OutboxRetention time.Duration
+
+	// Аутентификация: API-ключи "<имя>:<sha256 hex>:<права через пробел>",
+	// JWKS для проверки JWT, ожидаемые iss и aud (пустые — не проверяются)
+	// и права запросов без учётных данных (пустые — нужна аутентификация)
+	APIKeys         []string
+	JWKSFile        string
+	JWTIssuer       string
+	JWTAudience     string
+	AnonymousScopes string
+
+	// Ограничение частоты запросов: лимиты классов маршрутов "<класс>=<число>/<s|m|h>[:<burst>]",
+	// вёдра в Redis (общие для реплик) вместо памяти и доверие X-Forwarded-For/X-Real-IP
+	// (только за своим прокси, иначе клиент подменит себе IP)
+	RateLimits     []string
+	RateLimitRedis bool
+	TrustProxy     bool
+
+	// Хранение: заказы старше RetentionHotDays уходят в архив (table или file)
+	RetentionHotDays  int
+	RetentionStorage  string
+	RetentionDir      string
+	RetentionInterval time.Duration
+
+	// Сколько месяцев вперёд держать готовые секции orders/items
+	PartitionMonthsAhead int
+	PartitionInterval    time.Duration
+
+	// Реплики для чтения (DSN через запятую); пустой список — всё читается с primary
+	PostgresReplicaURLs  []string
+	ReplicaMaxLag        time.Duration
+	ReplicaStickyWindow  time.Duration
+	ReplicaCheckInterval time.Duration
+
+	// Пул соединений и устойчивость к сбоям Postgres
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+	DBQueryTimeout    time.Duration
+	DBPrepareStmt     bool
+	DBConnectRetries  int
+	DBConnectBackoff  time.Duration
+	DBBreakerFailures int
+	DBBreakerCooldown time.Duration
+	// Как часто в деградированном режиме проверять БД и повторять отложенные сообщения
+	DBProbeInterval time.Duration
+
+	// Сколько хранить в Redis результаты /stats
+	StatsCacheTTL time.Duration
+
+	// Файл курсов валют (JSON, версии по датам); пустой — суммы не пересчитываются
+	FXRatesFile       string
+	ReportingCurrency string
+
+	// Персональные данные: роль вызывающих без прав orders:admin и orders:pii
+	// (public, support, admin) и ключи шифрования PII: keyfile с ротацией ключей или один ключ
+	// (base64, 32 байта); оба пустые — без шифрования
+	PIIDefaultRole   string
+	PIIKeyfile       string
+	PIIEncryptionKey string
 }
 
 func Load() *Config {
@@ -21,7 +122,85 @@ func Load() *Config {
 		RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 		LogLevel:      getEnv("LOG_LEVEL", "info"),
+
+		HTTPReadHeaderTimeout: getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		HTTPReadTimeout:       getEnvDuration("HTTP_READ_TIMEOUT", 30*time.Second),
+		HTTPWriteTimeout:      getEnvDuration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		HTTPIdleTimeout:       getEnvDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+		HTTPShutdownTimeout:   getEnvDuration("HTTP_SHUTDOWN_TIMEOUT", 15*time.Second),
+		HTTPMaxHeaderBytes:    getEnvInt("HTTP_MAX_HEADER_BYTES", 64<<10),
+		HTTPMaxBodyBytes:      getEnvInt("HTTP_MAX_BODY_BYTES", 10<<20),
+		TLSCertFile:           getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:            getEnv("TLS_KEY_FILE", ""),
+		TLSReloadInterval:     getEnvDuration("TLS_RELOAD_INTERVAL", time.Minute),
+		HTTPH2C:               getEnvBool("HTTP_H2C", false),
+		CORSAllowedOrigins:    getEnvList("CORS_ALLOWED_ORIGINS", ""),
+		CompressionLevel:      getEnvInt("HTTP_COMPRESSION_LEVEL", 5),
+		HTTPCacheControl:      getEnv("HTTP_CACHE_CONTROL", "read=private, no-cache;search=private, no-cache;stats=private, max-age=60;write=no-store;admin=no-store"),
+
+		KafkaStatusTopic: getEnv("KAFKA_STATUS_TOPIC", "order-status"),
+
+		IngestSources: splitList(strings.ToLower(getEnv("INGEST_SOURCES", "kafka,http"))),
+		IngestFile:    getEnv("INGEST_FILE", ""),
+
+		OutboxTopic:     getEnv("OUTBOX_TOPIC", "order-events"),
+		OutboxInterval:  getEnvDuration("OUTBOX_INTERVAL", time.Second),
+		OutboxRetention: getEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+
+		APIKeys:         getEnvList("API_KEYS", ""),
+		JWKSFile:        getEnv("AUTH_JWKS_FILE", ""),
+		JWTIssuer:       getEnv("AUTH_JWT_ISSUER", ""),
+		JWTAudience:     getEnv("AUTH_JWT_AUDIENCE", ""),
+		AnonymousScopes: getEnv("AUTH_ANONYMOUS_SCOPES", ""),
+
+		RateLimits:     getEnvList("RATE_LIMITS", "read=20/s:40,search=5/s:10,write=50/s:100,stats=5/s:10,admin=5/s:10"),
+		RateLimitRedis: getEnvBool("RATE_LIMIT_REDIS", false),
+		TrustProxy:     getEnvBool("TRUST_PROXY", false),
+
+		RetentionHotDays:  getEnvInt("RETENTION_HOT_DAYS", 180),
+		RetentionStorage:  getEnv("RETENTION_STORAGE", "table"),
+		RetentionDir:      getEnv("RETENTION_DIR", "./archive"),
+		RetentionInterval: getEnvDuration("RETENTION_INTERVAL", time.Hour),
+
+		PartitionMonthsAhead: getEnvInt("PARTITION_MONTHS_AHEAD", 3),
+		PartitionInterval:    getEnvDuration("PARTITION_INTERVAL", 12*time.Hour),
+
+		PostgresReplicaURLs:  getEnvList("POSTGRES_REPLICA_URLS", ""),
+		ReplicaMaxLag:        getEnvDuration("REPLICA_MAX_LAG", 5*time.Second),
+		ReplicaStickyWindow:  getEnvDuration("REPLICA_STICKY_WINDOW", 5*time.Second),
+		ReplicaCheckInterval: getEnvDuration("REPLICA_CHECK_INTERVAL", 5*time.Second),
+
+		DBMaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 10),
+		DBConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBConnMaxIdleTime: getEnvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+		DBQueryTimeout:    getEnvDuration("DB_QUERY_TIMEOUT", 5*time.Second),
+		DBPrepareStmt:     getEnvBool("DB_PREPARE_STMT", true),
+		DBConnectRetries:  getEnvInt("DB_CONNECT_RETRIES", 10),
+		DBConnectBackoff:  getEnvDuration("DB_CONNECT_BACKOFF", time.Second),
+		DBBreakerFailures: getEnvInt("DB_BREAKER_FAILURES", 5),
+		DBBreakerCooldown: getEnvDuration("DB_BREAKER_COOLDOWN", 10*time.Second),
+		DBProbeInterval:   getEnvDuration("DB_PROBE_INTERVAL", 5*time.Second),
+
+		StatsCacheTTL: getEnvDuration("STATS_CACHE_TTL", time.Minute),
+
+		FXRatesFile:       getEnv("FX_RATES_FILE", ""),
+		ReportingCurrency: strings.ToUpper(getEnv("REPORTING_CURRENCY", "RUB")),
+
+		PIIDefaultRole:   getEnv("PII_DEFAULT_ROLE", "public"),
+		PIIKeyfile:       getEnv("PII_KEYFILE", ""),
+		PIIEncryptionKey: getEnv("PII_ENCRYPTION_KEY", ""),
+	}
+}
+
+// HasSource сообщает, включён ли источник заказов с таким именем
+func (c *Config) HasSource(name string) bool {
+	for _, s := range c.IngestSources {
+		if s == name {
+			return true
+		}
 	}
+	return false
 }
 
 func getEnv(key, fallback string) string {
@@ -30,3 +209,44 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvList(key, fallback string) []string {
+	return splitList(getEnv(key, fallback))
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,45 @@
+package consumer
+
+import "sync"
+
+// ChannelSource — источник в памяти, в основном для тестов
+type ChannelSource struct {
+	ch   chan []byte
+	done chan struct{}
+	once sync.Once
+}
+
+func NewChannelSource(buffer int) *ChannelSource {
+	return &ChannelSource{
+		ch:   make(chan []byte, buffer),
+		done: make(chan struct{}),
+	}
+}
+
+func (s *ChannelSource) Name() string {
+	return "channel"
+}
+
+// Send кладёт сообщение в источник
+func (s *ChannelSource) Send(data []byte) {
+	s.ch <- data
+}
+
+func (s *ChannelSource) Start(handle Handler) {
+	go func() {
+		defer close(s.done)
+		for data := range s.ch {
+			_ = process(s.Name(), handle, data)
+		}
+	}()
+}
+
+// Done закрывается, когда все отправленные до Close сообщения обработаны
+func (s *ChannelSource) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *ChannelSource) Close() error {
+	s.once.Do(func() { close(s.ch) })
+	return nil
+}
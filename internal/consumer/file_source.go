@@ -0,0 +1,136 @@
+package consumer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// FileSource читает заказы из файла или stdin (path == "-").
+// Поддерживает JSONL, последовательность JSON-объектов (в т.ч. многострочных,
+// как docs/model.json) и JSON-массивы заказов.
+type FileSource struct {
+	path   string
+	reader io.ReadCloser
+	done   chan struct{}
+	once   sync.Once
+}
+
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path, done: make(chan struct{})}
+}
+
+// NewReaderSource создаёт источник поверх произвольного io.Reader
+func NewReaderSource(r io.Reader) *FileSource {
+	return &FileSource{path: "reader", reader: io.NopCloser(r), done: make(chan struct{})}
+}
+
+func (s *FileSource) Name() string {
+	return "file:" + s.path
+}
+
+func (s *FileSource) Start(handle Handler) {
+	if s.reader == nil {
+		r, err := s.open()
+		if err != nil {
+			log.Printf("❌ [%s] Failed to open: %v", s.Name(), err)
+			close(s.done)
+			return
+		}
+		s.reader = r
+	}
+
+	go func() {
+		defer close(s.done)
+
+		count, err := s.readAll(handle)
+		if errors.Is(err, ErrStopped) {
+			log.Printf("⏹️  [%s] Чтение остановлено, обработано заказов: %d", s.Name(), count)
+			return
+		}
+		if err != nil {
+			log.Printf("❌ [%s] Failed to read: %v", s.Name(), err)
+		}
+		log.Printf("✅ [%s] Прочитано заказов: %d", s.Name(), count)
+	}()
+}
+
+// Done закрывается, когда источник дочитан до конца
+func (s *FileSource) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *FileSource) Close() error {
+	var err error
+	s.once.Do(func() {
+		if s.reader != nil {
+			err = s.reader.Close()
+		}
+	})
+	return err
+}
+
+func (s *FileSource) open() (io.ReadCloser, error) {
+	if s.path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(s.path)
+}
+
+// readAll передаёт заказы в handle и возвращает число обработанных. Ошибка
+// отдельного заказа не мешает следующим, а ErrStopped (Gate закрыт) прекращает
+// чтение: остаток файла не читается.
+func (s *FileSource) readAll(handle Handler) (int, error) {
+	count := 0
+	err := DecodeStream(bufio.NewReader(s.reader), func(data json.RawMessage) error {
+		err := process(s.Name(), handle, data)
+		if errors.Is(err, ErrStopped) {
+			return err
+		}
+		if err == nil {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// DecodeStream читает из r JSON-значения (JSONL/NDJSON, многострочные объекты
+// или массивы) и передаёт каждый заказ в fn. Ошибка fn прерывает чтение.
+func DecodeStream(r io.Reader, fn func(json.RawMessage) error) error {
+	dec := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		for _, data := range splitArray(raw) {
+			if err := fn(data); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// splitArray разворачивает JSON-массив в отдельные сообщения
+func splitArray(raw json.RawMessage) []json.RawMessage {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return []json.RawMessage{raw}
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(trimmed, &items); err != nil {
+		return []json.RawMessage{raw}
+	}
+	return items
+}
@@ -0,0 +1,113 @@
+package consumer
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrRetry — сообщение не обработано из-за временного сбоя хранилища.
+// Обработчик оборачивает им ошибку, чтобы Gate повторил сообщение, а не пропустил его.
+var ErrRetry = errors.New("temporary failure, retry later")
+
+// ErrStopped — ожидание прервано закрытием Gate
+var ErrStopped = errors.New("ingestion stopped")
+
+// Gate приостанавливает обработку сообщений, пока хранилище недоступно.
+// Источник блокируется в обработчике и не читает новые сообщения,
+// поэтому ничего не теряется: Kafka не получает commit, файл не дочитывается.
+type Gate struct {
+	retryInterval time.Duration
+
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{}
+	closed chan struct{}
+	once   sync.Once
+}
+
+// NewGate создаёт открытый Gate; retryInterval — пауза между повторами
+// сообщения, пока Gate закрыт или обработчик возвращает ErrRetry
+func NewGate(retryInterval time.Duration) *Gate {
+	return &Gate{
+		retryInterval: retryInterval,
+		resume:        make(chan struct{}),
+		closed:        make(chan struct{}),
+	}
+}
+
+// Pause останавливает приём новых сообщений
+func (g *Gate) Pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		g.paused = true
+		log.Printf("⏸️  Приём заказов приостановлен")
+	}
+}
+
+// Resume возобновляет приём и сразу будит ожидающие источники
+func (g *Gate) Resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		g.paused = false
+		close(g.resume)
+		g.resume = make(chan struct{})
+		log.Printf("▶️  Приём заказов возобновлён")
+	}
+}
+
+func (g *Gate) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// Close прерывает ожидание во всех обёрнутых обработчиках
+func (g *Gate) Close() {
+	g.once.Do(func() { close(g.closed) })
+}
+
+// Wrap возвращает обработчик, который ждёт, пока Gate на паузе, и повторяет
+// сообщение, пока handle возвращает ErrRetry
+func (g *Gate) Wrap(handle Handler) Handler {
+	return func(data []byte) error {
+		for {
+			if g.Paused() {
+				// Пробуем раз в retryInterval: попытка сама проверяет, поднялось ли хранилище
+				if err := g.wait(); err != nil {
+					return err
+				}
+			}
+
+			err := handle(data)
+			if !errors.Is(err, ErrRetry) {
+				return err
+			}
+			log.Printf("⏳ Temporary failure, message will be retried: %v", err)
+			if err := g.wait(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// wait ждёт Resume, закрытия или retryInterval
+func (g *Gate) wait() error {
+	g.mu.Lock()
+	resume := g.resume
+	g.mu.Unlock()
+
+	timer := time.NewTimer(g.retryInterval)
+	defer timer.Stop()
+
+	select {
+	case <-g.closed:
+		return ErrStopped
+	case <-resume:
+	case <-timer.C:
+	}
+	return nil
+}
@@ -2,49 +2,67 @@ package consumer
 
 import (
 	"context"
+	"errors"
+	"io"
 	"log"
 
-	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
 	"github.com/segmentio/kafka-go"
 )
 
 type KafkaConsumer struct {
-	reader  *kafka.Reader
-	service *service.OrderService
+	reader *kafka.Reader
+	topic  string
 }
 
-func NewKafkaConsumer(broker, topic string, service *service.OrderService) *KafkaConsumer {
+func NewKafkaConsumer(broker, topic, groupID string) *KafkaConsumer {
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:  []string{broker},
 		Topic:    topic,
-		GroupID:  "order-group",
+		GroupID:  groupID,
 		MinBytes: 10e3, // 10KB
 		MaxBytes: 10e6, // 10MB
 	})
 
 	return &KafkaConsumer{
-		reader:  reader,
-		service: service,
+		reader: reader,
+		topic:  topic,
 	}
 }
 
-func (c *KafkaConsumer) Start() {
+func (c *KafkaConsumer) Name() string {
+	return "kafka:" + c.topic
+}
+
+// Start читает сообщения и фиксирует offset только после обработки,
+// поэтому сообщение, обработка которого прервана остановкой, будет прочитано снова
+func (c *KafkaConsumer) Start(handle Handler) {
 	go func() {
+		ctx := context.Background()
 		for {
-			msg, err := c.reader.ReadMessage(context.Background())
+			msg, err := c.reader.FetchMessage(ctx)
+			if errors.Is(err, io.EOF) {
+				return // reader закрыт
+			}
 			if err != nil {
 				log.Printf("Error reading message: %v", err)
 				continue
 			}
 
-			log.Printf("📨 Получено сообщение: key=%s, value=%s", string(msg.Key), string(msg.Value))
+			log.Printf("📨 Получено сообщение: key=%s, value=%s", string(msg.Key), pii.MaskJSON(msg.Value, pii.LogPolicy()))
 
-			if err := c.service.SaveOrder(msg.Value); err != nil {
-				log.Printf("❌ Failed to process order: %v", err)
+			err = process(c.Name(), handle, msg.Value)
+			if errors.Is(err, ErrStopped) {
+				return
+			}
+			if err := c.reader.CommitMessages(ctx, msg); err != nil {
+				log.Printf("❌ [%s] Failed to commit offset: %v", c.Name(), err)
+			}
+			if err != nil {
 				continue
 			}
 
-			log.Printf("✅ Успешно обработан заказ: %s", msg.Key)
+			log.Printf("✅ [%s] Успешно обработано сообщение: %s", c.Name(), msg.Key)
 		}
 	}()
 }
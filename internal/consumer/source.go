@@ -0,0 +1,58 @@
+package consumer
+
+import (
+	"errors"
+	"log"
+)
+
+// Handler обрабатывает одно сырое сообщение с заказом
+type Handler func(data []byte) error
+
+// OrderSource — источник входящих заказов (Kafka, HTTP, файл, канал)
+type OrderSource interface {
+	// Name возвращает короткое имя источника для логов и конфига
+	Name() string
+	// Start запускает чтение в фоне и передаёт каждое сообщение в handle
+	Start(handle Handler)
+	// Close останавливает чтение и освобождает ресурсы
+	Close() error
+}
+
+// Group объединяет несколько источников в один
+type Group struct {
+	sources []OrderSource
+}
+
+func NewGroup(sources ...OrderSource) *Group {
+	return &Group{sources: sources}
+}
+
+func (g *Group) Name() string {
+	return "group"
+}
+
+func (g *Group) Start(handle Handler) {
+	for _, src := range g.sources {
+		log.Printf("▶️  Запуск источника заказов: %s", src.Name())
+		src.Start(handle)
+	}
+}
+
+func (g *Group) Close() error {
+	var errs []error
+	for _, src := range g.sources {
+		if err := src.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// process вызывает обработчик и логирует результат единообразно для всех источников
+func process(source string, handle Handler, data []byte) error {
+	if err := handle(data); err != nil {
+		log.Printf("❌ [%s] Failed to process order: %v", source, err)
+		return err
+	}
+	return nil
+}
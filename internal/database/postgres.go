@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Options — настройки подключения и пула соединений Postgres
+type Options struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	QueryTimeout    time.Duration
+
+	ConnectRetries int           // сколько раз повторять подключение при старте
+	ConnectBackoff time.Duration // начальная пауза между попытками, удваивается
+	Lazy           bool          // не проверять соединение при открытии (реплики)
+}
+
+// Open подключается к Postgres, повторяя попытки с экспоненциальной паузой,
+// и настраивает пул соединений и таймауты запросов
+func Open(dsn string, opts Options) (*gorm.DB, error) {
+	backoff := opts.ConnectBackoff
+	var lastErr error
+
+	for attempt := 0; attempt <= opts.ConnectRetries; attempt++ {
+		if attempt > 0 {
+			log.Printf("⏳ Postgres is not ready (%v), retry %d/%d in %s", lastErr, attempt, opts.ConnectRetries, backoff)
+			time.Sleep(backoff)
+			backoff = min(backoff*2, 30*time.Second)
+		}
+
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+			DisableAutomaticPing: opts.Lazy,
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if err := configure(db, opts); err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+
+	return nil, fmt.Errorf("connect to postgres after %d attempts: %w", opts.ConnectRetries+1, lastErr)
+}
+
+func configure(db *gorm.DB, opts Options) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(opts.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(opts.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+
+	if opts.QueryTimeout > 0 {
+		return db.Use(&queryTimeout{timeout: opts.QueryTimeout})
+	}
+	return nil
+}
+
+// queryTimeout — плагин gorm, ограничивающий время каждого запроса.
+// Запросы, у которых в контексте уже есть дедлайн, не трогает.
+type queryTimeout struct {
+	timeout time.Duration
+}
+
+const cancelKey = "database:query_timeout_cancel"
+
+func (p *queryTimeout) Name() string {
+	return "query_timeout"
+}
+
+func (p *queryTimeout) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	// Таймаут ставится первым колбэком и снимается последним, чтобы под ним
+	// выполнялись и транзакция, и preload/сохранение ассоциаций.
+	// Row не оборачиваем: строки читаются уже после колбэков, отмена контекста их оборвёт
+	for _, err := range []error{
+		cb.Create().Before("*").Register("timeout:before_create", p.before),
+		cb.Create().After("*").Register("timeout:after_create", p.after),
+		cb.Query().Before("*").Register("timeout:before_query", p.before),
+		cb.Query().After("*").Register("timeout:after_query", p.after),
+		cb.Update().Before("*").Register("timeout:before_update", p.before),
+		cb.Update().After("*").Register("timeout:after_update", p.after),
+		cb.Delete().Before("*").Register("timeout:before_delete", p.before),
+		cb.Delete().After("*").Register("timeout:after_delete", p.after),
+		cb.Raw().Before("*").Register("timeout:before_raw", p.before),
+		cb.Raw().After("*").Register("timeout:after_raw", p.after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *queryTimeout) before(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	db.Statement.Context = ctx
+	db.InstanceSet(cancelKey, cancel)
+}
+
+func (p *queryTimeout) after(db *gorm.DB) {
+	if cancel, ok := db.InstanceGet(cancelKey); ok {
+		cancel.(context.CancelFunc)()
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// AdminHandler — административные операции над заказами
+type AdminHandler struct {
+	service *service.OrderService
+}
+
+func NewAdminHandler(service *service.OrderService) *AdminHandler {
+	return &AdminHandler{service: service}
+}
+
+// CancelOrder — POST /admin/orders/{order_uid}/cancel?reason=..., отмена и мягкое удаление
+func (h *AdminHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	orderUID := chi.URLParam(r, "order_uid")
+	err := h.service.CancelOrder(orderUID, r.URL.Query().Get("reason"))
+	h.respond(w, err)
+}
+
+// DeleteOrder — DELETE /admin/orders/{order_uid}?reason=..., мягкое удаление
+func (h *AdminHandler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
+	orderUID := chi.URLParam(r, "order_uid")
+	err := h.service.DeleteOrder(orderUID, r.URL.Query().Get("reason"))
+	h.respond(w, err)
+}
+
+// PurgeOrder — POST /admin/orders/{order_uid}/purge, безвозвратное удаление
+func (h *AdminHandler) PurgeOrder(w http.ResponseWriter, r *http.Request) {
+	orderUID := chi.URLParam(r, "order_uid")
+	err := h.service.PurgeOrder(orderUID)
+	h.respond(w, err)
+}
+
+func (h *AdminHandler) respond(w http.ResponseWriter, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		writeError(w, http.StatusNotFound, "order not found")
+		return
+	}
+	if err != nil {
+		status, body := errorStatus(err)
+		writeJSON(w, status, body)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/auth"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+)
+
+// Authenticate определяет вызывающего по Authorization: Bearer <API-ключ или JWT>
+// или X-API-Key и кладёт в контекст его права и роль для маскирования PII.
+// Запросы без учётных данных получают права anonymous и роль fallback;
+// неверные учётные данные — 401, без понижения до анонимного доступа.
+func Authenticate(a *auth.Authenticator, anonymous []auth.Scope, fallback pii.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := &auth.Principal{Scopes: anonymous}
+			if token := credentials(r); token != "" {
+				var err error
+				if principal, err = a.Authenticate(token); err != nil {
+					unauthorized(w)
+					return
+				}
+			}
+			ctx := auth.WithPrincipal(r.Context(), principal)
+			ctx = pii.WithRole(ctx, principal.Role(fallback))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func credentials(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// RequireScope пропускает вызывающих с правом scope: анонимным отвечает 401, остальным — 403
+func RequireScope(scope auth.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := auth.PrincipalFrom(r.Context())
+			switch {
+			case principal.Has(scope):
+				next.ServeHTTP(w, r)
+			case principal.Anonymous():
+				unauthorized(w)
+			default:
+				writeError(w, http.StatusForbidden, "missing scope "+string(scope))
+			}
+		})
+	}
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="orders"`)
+	writeError(w, http.StatusUnauthorized, "unauthorized")
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/Sphirium/wb-tech-demo-lo/internal/api/v1"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+)
+
+// writeConditional отдаёт представление заказа с сильным ETag и Last-Modified и отвечает
+// 304 Not Modified, если у клиента та же версия (If-None-Match / If-Modified-Since).
+// version — версия представления; пусто — хеш тела ответа. Range не поддерживается:
+// тело всегда отдаётся целиком.
+func writeConditional(w http.ResponseWriter, r *http.Request, order *models.Order, dto any, version string) {
+	body, err := json.Marshal(dto)
+	if err != nil {
+		log.Printf("Failed to encode response: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	body = append(body, '\n')
+
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	if version == "" {
+		version = v1.ContentVersion(body)
+	}
+	// Сжатое тело байт в байт отличается от несжатого: у него свой сильный ETag
+	// (Vary: Accept-Encoding сжатому ответу ставит компрессор)
+	etag := `"` + version + `"`
+	if enc := contentEncoding(r); enc != "" {
+		etag = `"` + version + "-" + enc + `"`
+	} else {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	h.Set("ETag", etag)
+	h.Add("Vary", "Authorization")
+	h.Add("Vary", "X-API-Key")
+	if !order.UpdatedAt.IsZero() {
+		h.Set("Last-Modified", order.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, order.UpdatedAt) {
+		// Без Content-Type компрессор не сжимает пустой ответ
+		h.Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// notModified проверяет валидаторы запроса: If-None-Match (слабое сравнение,
+// RFC 9110 13.1.2), а без него — If-Modified-Since с точностью до секунды
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if modified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// ParseCacheControl разбирает Cache-Control по классам маршрутов:
+// "order=private, no-cache;search=no-store" (классы через ";", значение — как в заголовке)
+func ParseCacheControl(s string) (map[string]string, error) {
+	policies := map[string]string{}
+	for _, entry := range strings.Split(s, ";") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		class, value, ok := strings.Cut(entry, "=")
+		class, value = strings.TrimSpace(class), strings.TrimSpace(value)
+		if !ok || class == "" || value == "" {
+			return nil, fmt.Errorf("cache control %q: want <class>=<directives>", entry)
+		}
+		policies[class] = value
+	}
+	return policies, nil
+}
+
+// CacheControl ставит Cache-Control класса маршрутов class; без настройки — ничего
+func CacheControl(policies map[string]string, class string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		value, ok := policies[class]
+		if !ok {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
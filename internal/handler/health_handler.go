@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/health"
+)
+
+type HealthHandler struct {
+	monitor *health.Monitor
+}
+
+func NewHealthHandler(monitor *health.Monitor) *HealthHandler {
+	return &HealthHandler{monitor: monitor}
+}
+
+// Live — GET /healthz, процесс жив
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Ready — GET /readyz. В деградированном режиме сервис продолжает отдавать
+// заказы из кеша, поэтому остаётся готовым (200), но сообщает о деградации.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	status := h.monitor.Status()
+	if status.Status == "degraded" {
+		w.Header().Set("Warning", `199 - "degraded mode: database unavailable"`)
+	}
+	writeJSON(w, http.StatusOK, status)
+}
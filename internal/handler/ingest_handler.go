@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/cache"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/consumer"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
+)
+
+// maxBatchSize ограничивает число заказов в одном POST /orders:batch
+const maxBatchSize = 1000
+
+// IngestHandler принимает заказы по HTTP с той же проверкой и сохранением, что и consumer
+type IngestHandler struct {
+	service     *service.OrderService
+	idempotency cache.IdempotencyStoreInterface
+}
+
+func NewIngestHandler(service *service.OrderService, idempotency cache.IdempotencyStoreInterface) *IngestHandler {
+	return &IngestHandler{service: service, idempotency: idempotency}
+}
+
+// batchResult — результат обработки одного заказа из пакета
+type batchResult struct {
+	Index    int               `json:"index"`
+	Status   int               `json:"status"`
+	OrderUID string            `json:"order_uid,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+}
+
+type batchResponse struct {
+	Created int           `json:"created"`
+	Failed  int           `json:"failed"`
+	Results []batchResult `json:"results"`
+}
+
+// CreateOrder — POST /orders
+func (h *IngestHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	h.idempotent(w, r, func(body []byte) (int, any) {
+		order, err := h.service.CreateOrder(body)
+		if err != nil {
+			return errorStatus(err)
+		}
+		return http.StatusCreated, presentOrder(r, order)
+	})
+}
+
+// CreateOrdersBatch — POST /orders:batch, тело — JSON-массив или NDJSON
+func (h *IngestHandler) CreateOrdersBatch(w http.ResponseWriter, r *http.Request) {
+	h.idempotent(w, r, func(body []byte) (int, any) {
+		var messages []json.RawMessage
+		err := consumer.DecodeStream(bytes.NewReader(body), func(data json.RawMessage) error {
+			if len(messages) >= maxBatchSize {
+				return fmt.Errorf("batch exceeds %d orders", maxBatchSize)
+			}
+			messages = append(messages, data)
+			return nil
+		})
+		if err != nil {
+			return http.StatusBadRequest, errorResponse{Error: "invalid batch: " + err.Error()}
+		}
+		if len(messages) == 0 {
+			return http.StatusBadRequest, errorResponse{Error: "batch is empty"}
+		}
+
+		resp := batchResponse{Results: make([]batchResult, 0, len(messages))}
+		for i, data := range messages {
+			result := batchResult{Index: i, Status: http.StatusCreated}
+			order, err := h.service.CreateOrder(data)
+			if err != nil {
+				status, body := errorStatus(err)
+				result.Status, result.Error, result.Fields = status, body.Error, body.Fields
+				resp.Failed++
+			} else {
+				result.OrderUID = order.OrderUID
+				resp.Created++
+			}
+			resp.Results = append(resp.Results, result)
+		}
+
+		if resp.Failed > 0 {
+			return http.StatusMultiStatus, resp
+		}
+		return http.StatusCreated, resp
+	})
+}
+
+// idempotent читает тело запроса и выполняет fn не более одного раза на Idempotency-Key.
+// Повтор с тем же ключом и телом возвращает сохранённый ответ.
+func (h *IngestHandler) idempotent(w http.ResponseWriter, r *http.Request, fn func(body []byte) (int, any)) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBodyError(w, err, "failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" || h.idempotency == nil {
+		status, resp := fn(body)
+		writeJSON(w, status, resp)
+		return
+	}
+
+	fingerprint := requestFingerprint(r, body)
+	record, reserved, err := h.idempotency.Reserve(key, fingerprint)
+	if err != nil {
+		log.Printf("❌ Idempotency store error: %v", err)
+		writeError(w, http.StatusServiceUnavailable, "idempotency store unavailable")
+		return
+	}
+	if !reserved {
+		replay(w, record, fingerprint)
+		return
+	}
+
+	status, resp := fn(body)
+	data, err := json.Marshal(resp)
+	if err != nil {
+		_ = h.idempotency.Release(key)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	// 5xx не запоминаем, чтобы клиент мог повторить запрос с тем же ключом
+	if status >= http.StatusInternalServerError {
+		_ = h.idempotency.Release(key)
+	} else if err := h.idempotency.Complete(key, &cache.IdempotencyRecord{
+		Fingerprint: fingerprint,
+		Status:      status,
+		Body:        data,
+	}); err != nil {
+		log.Printf("❌ Failed to store idempotency record: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(data, '\n'))
+}
+
+func replay(w http.ResponseWriter, record *cache.IdempotencyRecord, fingerprint string) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		writeError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+	case record.Pending:
+		writeError(w, http.StatusConflict, "request with this Idempotency-Key is still in progress")
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(record.Status)
+		_, _ = w.Write(append(record.Body, '\n'))
+	}
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
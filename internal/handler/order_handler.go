@@ -2,8 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
 
+	v1 "github.com/Sphirium/wb-tech-demo-lo/internal/api/v1"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
 	"github.com/go-chi/chi/v5"
 )
@@ -22,13 +29,270 @@ func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "order_uid is required", http.StatusBadRequest)
 		return
 	}
+	fieldset, ok := fieldsetParam(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := h.service.GetOrderByUIDWith(orderUID, includeFor(fieldset))
+	h.writeOrder(w, r, order, fieldset, err)
+}
+
+// GetOrderByTrack — GET /order/by-track/{track}
+func (h *OrderHandler) GetOrderByTrack(w http.ResponseWriter, r *http.Request) {
+	fieldset, ok := fieldsetParam(w, r)
+	if !ok {
+		return
+	}
+	order, err := h.service.GetOrderByTrackNumberWith(chi.URLParam(r, "track"), includeFor(fieldset))
+	h.writeOrder(w, r, order, fieldset, err)
+}
 
-	order, err := h.service.GetOrderByUID(orderUID)
+// GetOrderByTransaction — GET /order/by-transaction/{tx}
+func (h *OrderHandler) GetOrderByTransaction(w http.ResponseWriter, r *http.Request) {
+	fieldset, ok := fieldsetParam(w, r)
+	if !ok {
+		return
+	}
+	order, err := h.service.GetOrderByTransactionWith(chi.URLParam(r, "tx"), includeFor(fieldset))
+	h.writeOrder(w, r, order, fieldset, err)
+}
+
+// fieldsetParam разбирает ?fields= и ?include=; при ошибке сам отвечает 400
+func fieldsetParam(w http.ResponseWriter, r *http.Request) (*v1.Fieldset, bool) {
+	query := r.URL.Query()
+	fieldset, err := v1.ParseFieldset(query.Get("fields"), query.Get("include"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid query parameters", Fields: map[string]string{"fields": err.Error()}})
+		return nil, false
+	}
+	return fieldset, true
+}
+
+// includeFor — ассоциации, которые нужно загрузить для выборки полей
+func includeFor(fieldset *v1.Fieldset) repository.Include {
+	return repository.Include{
+		Delivery: fieldset.Includes(v1.AssocDelivery),
+		Payment:  fieldset.Includes(v1.AssocPayment),
+		Items:    fieldset.Includes(v1.AssocItems),
+	}
+}
+
+// writeUnavailable — 503 с Retry-After, когда БД недоступна
+func writeUnavailable(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", unavailableRetryAfter)
+	http.Error(w, "Storage temporarily unavailable", http.StatusServiceUnavailable)
+}
+
+// writeOrder отдаёт найденный заказ (только поля из fieldset, если она задана);
+// при недоступной БД — 503, иначе при ошибке — 404
+func (h *OrderHandler) writeOrder(w http.ResponseWriter, r *http.Request, order *models.Order, fieldset *v1.Fieldset, err error) {
+	if errors.Is(err, service.ErrUnavailable) {
+		writeUnavailable(w)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Order not found", http.StatusNotFound)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(order)
+	h.markDegraded(w)
+	dto := presentOrder(r, order)
+	if fieldset == nil {
+		// Представление зависит от роли вызывающего, поэтому роль входит в версию
+		writeConditional(w, r, order, dto, v1.Version(order)+"."+string(pii.RoleFrom(r.Context())))
+		return
+	}
+	trimmed, err := fieldset.Apply(dto)
+	if err != nil {
+		log.Printf("Failed to apply fieldset: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	// Неполный заказ мог быть загружен без части ассоциаций, поэтому его
+	// версия — хеш самого ответа
+	writeConditional(w, r, order, trimmed, "")
+}
+
+// markDegraded помечает ответ: в деградированном режиме БД недоступна, значит данные взяты из кеша
+func (h *OrderHandler) markDegraded(w http.ResponseWriter) {
+	if h.service.Degraded() {
+		w.Header().Set("X-Data-Source", "cache")
+		w.Header().Set("Warning", `111 - "Revalidation Failed: database unavailable, served from cache"`)
+	}
+}
+
+// GetOrderHistory — GET /order/{order_uid}/history, хронология смены статусов
+func (h *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+	orderUID := chi.URLParam(r, "order_uid")
+	if orderUID == "" {
+		http.Error(w, "order_uid is required", http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.service.GetStatusHistory(orderUID)
+	if errors.Is(err, service.ErrUnavailable) {
+		writeUnavailable(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Order not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, v1.FromHistory(history))
+}
+
+// searchResponse — страница результатов поиска; orders — []v1.Order или выборка полей
+type searchResponse struct {
+	Orders any `json:"orders"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// SearchOrders — GET /orders/search?q=&track_number=&transaction=&rid=&nm_id=&chrt_id=&limit=&offset=&fields=&include=
+func (h *OrderHandler) SearchOrders(w http.ResponseWriter, r *http.Request) {
+	fieldset, ok := fieldsetParam(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	search := repository.OrderSearch{
+		Text:        query.Get("q"),
+		TrackNumber: query.Get("track_number"),
+		Transaction: query.Get("transaction"),
+		RID:         query.Get("rid"),
+	}
+
+	limit, offset, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	search.Limit, search.Offset = limit, offset
+
+	fields := map[string]string{}
+	for name, dst := range map[string]*int64{"nm_id": &search.NMID, "chrt_id": &search.ChrtID} {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				fields[name] = "must be an integer"
+			}
+			*dst = n
+		}
+	}
+	if len(fields) > 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid query parameters", Fields: fields})
+		return
+	}
+
+	orders, err := h.service.SearchOrders(&search)
+	if err != nil {
+		status, resp := errorStatus(err)
+		writeJSON(w, status, resp)
+		return
+	}
+	list, err := presentOrderList(r, orders, fieldset)
+	if err != nil {
+		log.Printf("Failed to apply fieldset: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(w, http.StatusOK, searchResponse{Orders: list, Limit: search.Limit, Offset: search.Offset})
+}
+
+// customerOrdersResponse — страница заказов клиента
+type customerOrdersResponse struct {
+	Orders any `json:"orders"`
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// GetCustomerOrders — GET /customers/{id}/orders?limit=&offset=&fields=&include=
+func (h *OrderHandler) GetCustomerOrders(w http.ResponseWriter, r *http.Request) {
+	fieldset, ok := fieldsetParam(w, r)
+	if !ok {
+		return
+	}
+	limit, offset, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	orders, total, err := h.service.GetCustomerOrders(chi.URLParam(r, "id"), limit, offset)
+	if err != nil {
+		status, resp := errorStatus(err)
+		writeJSON(w, status, resp)
+		return
+	}
+	if limit == 0 {
+		limit = service.DefaultPageLimit
+	}
+	list, err := presentOrderList(r, orders, fieldset)
+	if err != nil {
+		log.Printf("Failed to apply fieldset: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	h.markDegraded(w)
+	writeJSON(w, http.StatusOK, customerOrdersResponse{Orders: list, Total: total, Limit: limit, Offset: offset})
+}
+
+// pageParams разбирает limit и offset; при ошибке сам отвечает 400
+func pageParams(w http.ResponseWriter, r *http.Request) (limit, offset int, ok bool) {
+	fields := map[string]string{}
+	for name, dst := range map[string]*int{"limit": &limit, "offset": &offset} {
+		if value := r.URL.Query().Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				fields[name] = "must be an integer"
+			}
+			*dst = n
+		}
+	}
+	if len(fields) > 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid query parameters", Fields: fields})
+		return 0, 0, false
+	}
+	return limit, offset, true
+}
+
+type batchGetRequest struct {
+	OrderUIDs []string `json:"order_uids"`
+}
+
+type batchGetResponse struct {
+	Orders  any      `json:"orders"`
+	Missing []string `json:"missing"`
+}
+
+// BatchGetOrders — POST /orders:batchGet?fields=&include=, тело {"order_uids":[...]}
+func (h *OrderHandler) BatchGetOrders(w http.ResponseWriter, r *http.Request) {
+	fieldset, ok := fieldsetParam(w, r)
+	if !ok {
+		return
+	}
+	var req batchGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err, "invalid request body: "+err.Error())
+		return
+	}
+
+	orders, missing, err := h.service.GetOrdersByUIDs(req.OrderUIDs)
+	if err != nil {
+		status, resp := errorStatus(err)
+		writeJSON(w, status, resp)
+		return
+	}
+	if missing == nil {
+		missing = []string{}
+	}
+	list, err := presentOrderList(r, orders, fieldset)
+	if err != nil {
+		log.Printf("Failed to apply fieldset: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	h.markDegraded(w)
+	writeJSON(w, http.StatusOK, batchGetResponse{Orders: list, Missing: missing})
 }
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	v1 "github.com/Sphirium/wb-tech-demo-lo/internal/api/v1"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+)
+
+// presentOrder строит ответ API v1 с полями, замаскированными по роли вызывающего
+func presentOrder(r *http.Request, order *models.Order) *v1.Order {
+	dto := v1.FromOrder(order)
+	if dto != nil {
+		dto.Redact(pii.PolicyFor(pii.RoleFrom(r.Context())))
+	}
+	return dto
+}
+
+func presentOrders(r *http.Request, orders []models.Order) []v1.Order {
+	dtos := v1.FromOrders(orders)
+	policy := pii.PolicyFor(pii.RoleFrom(r.Context()))
+	for i := range dtos {
+		dtos[i].Redact(policy)
+	}
+	return dtos
+}
+
+// presentOrderList — список заказов для ответа: целиком или только поля из fieldset
+func presentOrderList(r *http.Request, orders []models.Order, fieldset *v1.Fieldset) (any, error) {
+	dtos := presentOrders(r, orders)
+	if fieldset == nil {
+		return dtos, nil
+	}
+	trimmed := make([]map[string]json.RawMessage, len(dtos))
+	for i := range dtos {
+		var err error
+		if trimmed[i], err = fieldset.Apply(&dtos[i]); err != nil {
+			return nil, err
+		}
+	}
+	return trimmed, nil
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/auth"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/ratelimit"
+)
+
+// RateLimit ограничивает частоту запросов класса маршрутов class для каждого
+// клиента: аутентифицированного — по имени ключа или sub токена, анонимного — по IP.
+// Ставится после Authenticate; за прокси адрес клиента берётся из middleware.RealIP.
+func RateLimit(l *ratelimit.Limiter, class string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		limit := l.Limit(class)
+		if limit.Unlimited() {
+			return next
+		}
+		policy := strconv.Itoa(limit.Burst) + ";w=" + strconv.Itoa(ceilSeconds(limit.Window()))
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res := l.Take(r.Context(), class, clientKey(r))
+
+			// Заголовки по draft-ietf-httpapi-ratelimit-headers
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			h.Set("RateLimit-Policy", policy)
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(res.RetryAfter))))
+				writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientKey(r *http.Request) string {
+	if p := auth.PrincipalFrom(r.Context()); !p.Anonymous() {
+		return "sub:" + p.Subject
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr // RealIP кладёт адрес без порта
+	}
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
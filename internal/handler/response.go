@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
+)
+
+// errorResponse — тело ответа с ошибкой
+type errorResponse struct {
+	Error  string            `json:"error"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// unavailableRetryAfter — через сколько секунд повторять запрос, если БД недоступна
+const unavailableRetryAfter = "10"
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if status == http.StatusServiceUnavailable && w.Header().Get("Retry-After") == "" {
+		w.Header().Set("Retry-After", unavailableRetryAfter)
+	}
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
+
+// errorStatus сопоставляет ошибку сервиса с HTTP-статусом и телом ответа
+func errorStatus(err error) (int, errorResponse) {
+	var verr *service.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		return http.StatusUnprocessableEntity, errorResponse{Error: "validation failed", Fields: verr.Fields}
+	case errors.Is(err, service.ErrInvalidPayload):
+		return http.StatusBadRequest, errorResponse{Error: err.Error()}
+	case errors.Is(err, service.ErrOrderExists),
+		errors.Is(err, service.ErrInvalidTransition),
+		errors.Is(err, service.ErrStatusConflict):
+		return http.StatusConflict, errorResponse{Error: err.Error()}
+	case errors.Is(err, service.ErrUnavailable):
+		return http.StatusServiceUnavailable, errorResponse{Error: "storage temporarily unavailable"}
+	default:
+		log.Printf("❌ Internal error: %v", err)
+		return http.StatusInternalServerError, errorResponse{Error: "internal error"}
+	}
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// SecurityHeaders ставит заголовки, запрещающие браузеру угадывать тип содержимого,
+// встраивать страницы во фреймы и грузить ресурсы с чужих источников.
+// hsts — сервер доступен только по HTTPS.
+func SecurityHeaders(hsts bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", "no-referrer")
+			h.Set("Cross-Origin-Opener-Policy", "same-origin")
+			// Веб-интерфейс — одна страница со встроенными стилями и скриптом
+			h.Set("Content-Security-Policy", "default-src 'self'; script-src 'self' 'unsafe-inline'; "+
+				"style-src 'self' 'unsafe-inline'; frame-ancestors 'none'; base-uri 'none'; form-action 'self'")
+			if hsts {
+				h.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// BodyLimit ограничивает размер тела запроса; чтение сверх лимита возвращает
+// *http.MaxBytesError, который обработчики превращают в 413
+func BodyLimit(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeBodyError отвечает на ошибку чтения тела запроса
+func writeBodyError(w http.ResponseWriter, err error, msg string) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+	writeError(w, http.StatusBadRequest, msg)
+}
+
+// CORSOptions — с каких источников браузеру разрешено обращаться к API
+type CORSOptions struct {
+	// AllowedOrigins — точные источники ("https://ui.example.com") или "*"
+	AllowedOrigins []string
+	MaxAge         int
+}
+
+const (
+	corsMethods = "GET, POST, DELETE, OPTIONS"
+	corsHeaders = "Authorization, Content-Type, Idempotency-Key, X-API-Key, If-None-Match, If-Modified-Since"
+	// Заголовки ответа, которые может прочитать скрипт веб-интерфейса
+	corsExposed = "ETag, Last-Modified, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Warning"
+)
+
+// CORS отвечает на preflight-запросы и разрешает чтение ответов с разрешённых
+// источников. Ставится до аутентификации: preflight идёт без учётных данных.
+// Без разрешённых источников браузер допускает только запросы с того же источника.
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
+	return func(next http.Handler) http.Handler {
+		if len(opts.AllowedOrigins) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			allowed := origin != "" && (anyOrigin || slices.Contains(opts.AllowedOrigins, origin))
+			if allowed {
+				h.Set("Access-Control-Allow-Origin", origin)
+				h.Set("Access-Control-Expose-Headers", corsExposed)
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+				if allowed {
+					h.Set("Access-Control-Allow-Methods", corsMethods)
+					h.Set("Access-Control-Allow-Headers", corsHeaders)
+					h.Set("Access-Control-Max-Age", strconv.Itoa(opts.MaxAge))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// compressEncodings — кодировки Compress в порядке предпочтения (как у компрессора chi)
+var compressEncodings = []string{"br", "gzip", "deflate"}
+
+type encodingKey struct{}
+
+// Compress сжимает ответы JSON, HTML и текста в brotli или gzip — что клиент
+// принимает, brotli предпочтительнее. Выбранная кодировка кладётся в контекст
+// запроса: сильный ETag сжатого ответа должен отличаться от несжатого.
+func Compress(level int) func(http.Handler) http.Handler {
+	c := middleware.NewCompressor(level, "application/json", "text/html", "text/plain", "text/css", "text/javascript")
+	c.SetEncoder("br", func(w io.Writer, level int) io.Writer {
+		return brotli.NewWriterLevel(w, min(level, brotli.BestCompression))
+	})
+	return func(next http.Handler) http.Handler {
+		compressed := c.Handler(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if enc := negotiateEncoding(r.Header.Get("Accept-Encoding")); enc != "" {
+				r = r.WithContext(context.WithValue(r.Context(), encodingKey{}, enc))
+			}
+			compressed.ServeHTTP(w, r)
+		})
+	}
+}
+
+// negotiateEncoding повторяет выбор компрессора chi: первая из compressEncodings,
+// упомянутая в Accept-Encoding
+func negotiateEncoding(accept string) string {
+	accepted := strings.Split(strings.ToLower(accept), ",")
+	for _, enc := range compressEncodings {
+		for _, v := range accepted {
+			if strings.Contains(v, enc) {
+				return enc
+			}
+		}
+	}
+	return ""
+}
+
+// contentEncoding — кодировка, в которой Compress отдаст сжимаемый ответ; пусто — без сжатия
+func contentEncoding(r *http.Request) string {
+	enc, _ := r.Context().Value(encodingKey{}).(string)
+	return enc
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultStatsPeriod — период по умолчанию, если from не задан
+const defaultStatsPeriod = 30 * 24 * time.Hour
+
+type StatsHandler struct {
+	service *service.StatsService
+}
+
+func NewStatsHandler(service *service.StatsService) *StatsHandler {
+	return &StatsHandler{service: service}
+}
+
+// Summary — GET /stats?from=&to=&currency=
+func (h *StatsHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	filter, ok := statsFilter(w, r)
+	if !ok {
+		return
+	}
+
+	summary, err := h.service.Summary(filter)
+	if err != nil {
+		status, resp := errorStatus(err)
+		writeJSON(w, status, resp)
+		return
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// Aggregate — GET /stats/{dimension}?from=&to=&currency=&limit=
+func (h *StatsHandler) Aggregate(w http.ResponseWriter, r *http.Request) {
+	filter, ok := statsFilter(w, r)
+	if !ok {
+		return
+	}
+
+	buckets, err := h.service.Aggregate(chi.URLParam(r, "dimension"), filter)
+	if err != nil {
+		status, resp := errorStatus(err)
+		writeJSON(w, status, resp)
+		return
+	}
+	writeJSON(w, http.StatusOK, buckets)
+}
+
+// statsFilter разбирает период. from и to принимают дату (YYYY-MM-DD) или RFC3339;
+// дата в to включается целиком. По умолчанию — последние 30 дней, включая сегодня.
+func statsFilter(w http.ResponseWriter, r *http.Request) (repository.StatsFilter, bool) {
+	query := r.URL.Query()
+	filter := repository.StatsFilter{Currency: query.Get("currency")}
+	fields := map[string]string{}
+
+	// По умолчанию — до конца текущего дня: период из целых дней считается по итогам
+	filter.To = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if value := query.Get("to"); value != "" {
+		to, dateOnly, err := parseStatsTime(value)
+		if err != nil {
+			fields["to"] = "must be a date (YYYY-MM-DD) or RFC3339 timestamp"
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = to
+	}
+
+	filter.From = filter.To.Add(-defaultStatsPeriod)
+	if value := query.Get("from"); value != "" {
+		from, _, err := parseStatsTime(value)
+		if err != nil {
+			fields["from"] = "must be a date (YYYY-MM-DD) or RFC3339 timestamp"
+		}
+		filter.From = from
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			fields["limit"] = "must be an integer"
+		}
+		filter.Limit = limit
+	}
+
+	if len(fields) > 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid query parameters", Fields: fields})
+		return filter, false
+	}
+	return filter, true
+}
+
+func parseStatsTime(value string) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, value)
+	return t.UTC(), false, err
+}
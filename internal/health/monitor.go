@@ -0,0 +1,123 @@
+package health
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/breaker"
+)
+
+// Pauser — приём заказов, который приостанавливается на время деградации
+type Pauser interface {
+	Pause()
+	Resume()
+}
+
+// Status — состояние сервиса для /readyz
+type Status struct {
+	Status   string     `json:"status"` // ok или degraded
+	Database string     `json:"database"`
+	Since    *time.Time `json:"degraded_since,omitempty"`
+	Ingest   string     `json:"ingest"` // running или paused
+}
+
+// Monitor переводит сервис в деградированный режим, когда предохранитель БД
+// размыкается: чтения идут только из кеша, приём заказов на паузе.
+// Пока цепь разомкнута, Monitor сам проверяет БД пробным ping и после
+// восстановления возобновляет приём.
+type Monitor struct {
+	breaker  *breaker.Breaker
+	ping     func() error
+	ingest   Pauser
+	interval time.Duration
+
+	mu    sync.Mutex
+	since time.Time // ноль — БД доступна
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewMonitor(b *breaker.Breaker, ping func() error, ingest Pauser, interval time.Duration) *Monitor {
+	m := &Monitor{
+		breaker:  b,
+		ping:     ping,
+		ingest:   ingest,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+	b.OnStateChange(func(breaker.State) { m.sync() })
+	return m
+}
+
+func (m *Monitor) Start() {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+			}
+			m.Probe()
+		}
+	}()
+}
+
+// Probe проверяет БД через предохранитель, если цепь не замкнута.
+// Пока не истёк cooldown, предохранитель отклоняет пробу без обращения к БД.
+func (m *Monitor) Probe() {
+	if m.breaker.State() == breaker.Closed {
+		return
+	}
+	_ = m.breaker.Do(m.ping)
+	m.sync()
+}
+
+// Degraded — БД недоступна
+func (m *Monitor) Degraded() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !m.since.IsZero()
+}
+
+func (m *Monitor) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st := Status{Status: "ok", Database: m.breaker.State().String(), Ingest: "running"}
+	if !m.since.IsZero() {
+		since := m.since
+		st.Status, st.Since, st.Ingest = "degraded", &since, "paused"
+	}
+	return st
+}
+
+// sync приводит режим к текущему состоянию предохранителя. Состояние читается
+// заново, т.к. уведомления о смене приходят асинхронно и могут опоздать.
+func (m *Monitor) sync() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch state := m.breaker.State(); {
+	case state == breaker.Closed && !m.since.IsZero():
+		log.Printf("✅ Database is back after %s, leaving degraded mode", time.Since(m.since).Round(time.Second))
+		m.since = time.Time{}
+		m.ingest.Resume()
+	case state == breaker.Open && m.since.IsZero():
+		log.Printf("⚠️  Database is unavailable, entering degraded mode: reads from cache, ingestion paused")
+		m.since = time.Now()
+		m.ingest.Pause()
+	}
+}
+
+func (m *Monitor) Close() {
+	close(m.stop)
+	m.wg.Wait()
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Способы хранения архива
+const (
+	ArchiveStorageTable = "table"
+	ArchiveStorageFile  = "file"
+)
+
+// ArchiveRecord — строка индекса архива; сам заказ лежит в Payload или в файле Location
+type ArchiveRecord struct {
+	OrderUID    string          `gorm:"type:uuid;primaryKey"`
+	TrackNumber string          `gorm:"size:64;not null"`
+	CustomerID  string          `gorm:"size:128;not null"`
+	DateCreated time.Time       `gorm:"not null"`
+	Storage     string          `gorm:"size:8;not null"`
+	Location    string          `gorm:"not null;default:''"`
+	Payload     json.RawMessage `gorm:"type:jsonb"`
+	ArchivedAt  time.Time       `gorm:"not null"`
+	// DeletedAt переносится из заказа: удалённый до архивации заказ остаётся скрытым
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (ArchiveRecord) TableName() string {
+	return "orders_archive"
+}
+
+// ArchivedOrder — снимок заказа в архиве
+type ArchivedOrder struct {
+	Order   *Order          `json:"order"`
+	History []StatusHistory `json:"history"`
+}
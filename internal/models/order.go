@@ -1,43 +1,77 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/money"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	// Столбцы с тегом serializer:pii шифруются, если заданы ключи (pii.SetKeyring)
+	schema.RegisterSerializer("pii", pii.Serializer{})
+}
 
 // Order — основная сущность заказа
 type Order struct {
-	ID                uint   `json:"id"`
-	OrderUID          string `json:"order_uid" gorm:"type:uuid;uniqueIndex;not null"`
-	TrackNumber       string `json:"track_number" gorm:"size:64;uniqueIndex;not null"`
-	Entry             string `json:"entry" gorm:"size:10;not null"`
-	Locale            string `json:"locale" gorm:"size:10;not null"`
-	InternalSignature string `json:"internal_signature"`
-	CustomerID        string `json:"customer_id" gorm:"size:128;not null"`
-	DeliveryService   string `json:"delivery_service" gorm:"size:64;not null"`
-	Shardkey          string `json:"shardkey" gorm:"size:2;not null"`
-	SMID              int    `json:"sm_id" gorm:"not null"`
-	DateCreated       string `json:"date_created" gorm:"not null"`
-	OofShard          string `json:"oof_shard" gorm:"size:2;not null"`
+	ID                uint      `json:"id"`
+	OrderUID          string    `json:"order_uid" gorm:"type:uuid;uniqueIndex;not null"`
+	TrackNumber       string    `json:"track_number" gorm:"size:64;uniqueIndex;not null"`
+	Entry             string    `json:"entry" gorm:"size:10;not null"`
+	Locale            string    `json:"locale" gorm:"size:10;not null"`
+	InternalSignature string    `json:"internal_signature" gorm:"type:text;serializer:pii"`
+	CustomerID        string    `json:"customer_id" gorm:"size:128;not null"`
+	DeliveryService   string    `json:"delivery_service" gorm:"size:64;not null"`
+	Shardkey          string    `json:"shardkey" gorm:"size:2;not null"`
+	SMID              int       `json:"sm_id" gorm:"not null"`
+	DateCreated       Timestamp `json:"date_created" gorm:"not null"`
+	OofShard          string    `json:"oof_shard" gorm:"size:2;not null"`
+
+	// Исходное смещение DateCreated в секундах: TIMESTAMPTZ его не хранит
+	DateCreatedOffset int `json:"-" gorm:"not null;default:0"`
+
+	Status OrderStatus `json:"status" gorm:"size:16;not null;default:created"`
 
 	// Ассоциации
 	Delivery *Delivery `json:"delivery" gorm:"foreignKey:OrderID;references:OrderUID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Payment  *Payment  `json:"payment" gorm:"foreignKey:Transaction;references:OrderUID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Payment  *Payment  `json:"payment" gorm:"foreignKey:OrderID;references:OrderUID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Items    []Item    `json:"items" gorm:"foreignKey:OrderID;references:OrderUID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 
 	// GORM timestamps
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	DeletedAt *time.Time `json:"-" gorm:"index"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Version — версия представления заказа в API (основа ETag). Не хранится
+	// в БД: кеш сохраняет её вместе с заказом, иначе она вычисляется (v1.Version).
+	Version string `json:"-" gorm:"-"`
 }
 
-// Delivery — данные доставки
+// BeforeSave запоминает смещение DateCreated
+func (o *Order) BeforeSave(tx *gorm.DB) error {
+	o.DateCreatedOffset = o.DateCreated.Offset()
+	return nil
+}
+
+// AfterFind возвращает DateCreated исходное смещение
+func (o *Order) AfterFind(tx *gorm.DB) error {
+	o.DateCreated = o.DateCreated.WithOffset(o.DateCreatedOffset)
+	return nil
+}
+
+// Delivery — данные доставки. Имя, телефон, адрес и email — персональные данные:
+// при включённом шифровании хранятся зашифрованными, поэтому столбцы TEXT.
 type Delivery struct {
 	OrderID string `json:"-" gorm:"type:uuid;primaryKey;not null"`
-	Name    string `json:"name" gorm:"size:128;not null"`
-	Phone   string `json:"phone" gorm:"size:15;not null"`
+	Name    string `json:"name" gorm:"type:text;serializer:pii;not null"`
+	Phone   string `json:"phone" gorm:"type:text;serializer:pii;not null"`
 	Zip     string `json:"zip" gorm:"size:10;not null"`
 	City    string `json:"city" gorm:"size:64;not null"`
-	Address string `json:"address" gorm:"size:64;not null"`
+	Address string `json:"address" gorm:"type:text;serializer:pii;not null"`
 	Region  string `json:"region" gorm:"size:64;not null"`
-	Email   string `json:"email" gorm:"size:128"`
+	Email   string `json:"email" gorm:"type:text;serializer:pii"`
 }
 
 func (Delivery) TableName() string {
@@ -46,24 +80,87 @@ func (Delivery) TableName() string {
 
 // Payment — данные оплаты
 type Payment struct {
-	Transaction   string    `json:"transaction" gorm:"type:uuid;primaryKey;not null"`
-	OrderID       string    `json:"-" gorm:"type:uuid;uniqueIndex;not null"`
-	RequestID     string    `json:"request_id" gorm:"size:64"`
-	Currency      string    `json:"currency" gorm:"size:10;not null"`
-	Provider      string    `json:"provider" gorm:"size:32;not null"`
-	Amount        int       `json:"amount" gorm:"not null"`
-	PaymentDt     int64     `json:"payment_dt" gorm:"-"`
-	PaymentDtTime time.Time `gorm:"column:payment_dt;not null"`
-	Bank          string    `json:"bank" gorm:"size:20;not null"`
-	DeliveryCost  int       `json:"delivery_cost" gorm:"not null"`
-	GoodsTotal    int       `json:"goods_total" gorm:"not null"`
-	CustomFee     int       `json:"custom_fee" gorm:"not null"`
+	Transaction  string   `json:"transaction" gorm:"type:uuid;primaryKey;not null"`
+	OrderID      string   `json:"-" gorm:"type:uuid;uniqueIndex;not null"`
+	RequestID    string   `json:"request_id" gorm:"size:64"`
+	Currency     string   `json:"currency" gorm:"size:10;not null"`
+	Provider     string   `json:"provider" gorm:"size:32;not null"`
+	Amount       int      `json:"amount" gorm:"not null"`
+	PaymentDt    UnixTime `json:"payment_dt" gorm:"not null"`
+	Bank         string   `json:"bank" gorm:"size:20;not null"`
+	DeliveryCost int      `json:"delivery_cost" gorm:"not null"`
+	GoodsTotal   int      `json:"goods_total" gorm:"not null"`
+	CustomFee    int      `json:"custom_fee" gorm:"not null"`
+
+	// Единица PaymentDt в JSON: TIMESTAMPTZ хранит только момент
+	PaymentDtMillis bool `json:"-" gorm:"not null;default:false"`
+
+	// Суммы в валюте отчётности; заполняются сервисом, если настроены курсы
+	Normalized *NormalizedPayment `json:"normalized,omitempty" gorm:"-"`
+
+	// Хранение Normalized: суммы в минимальных единицах NormalizedCurrency
+	NormalizedCurrency     string     `json:"-" gorm:"size:3;not null;default:''"`
+	RateDate               *time.Time `json:"-" gorm:"type:date"`
+	AmountNormalized       int64      `json:"-" gorm:"not null;default:0"`
+	DeliveryCostNormalized int64      `json:"-" gorm:"not null;default:0"`
+	GoodsTotalNormalized   int64      `json:"-" gorm:"not null;default:0"`
+	CustomFeeNormalized    int64      `json:"-" gorm:"not null;default:0"`
 }
 
 func (Payment) TableName() string {
 	return "payment"
 }
 
+// NormalizedPayment — суммы оплаты, пересчитанные в валюту отчётности по курсу на RateDate
+type NormalizedPayment struct {
+	RateDate     string      `json:"rate_date"`
+	Amount       money.Money `json:"amount"`
+	DeliveryCost money.Money `json:"delivery_cost"`
+	GoodsTotal   money.Money `json:"goods_total"`
+	CustomFee    money.Money `json:"custom_fee"`
+}
+
+// BeforeSave раскладывает Normalized и единицу PaymentDt по столбцам
+func (p *Payment) BeforeSave(tx *gorm.DB) error {
+	p.PaymentDtMillis = p.PaymentDt.Millis
+	if p.Normalized == nil {
+		p.NormalizedCurrency, p.RateDate = "", nil
+		p.AmountNormalized, p.DeliveryCostNormalized, p.GoodsTotalNormalized, p.CustomFeeNormalized = 0, 0, 0, 0
+		return nil
+	}
+
+	rateDate, err := time.Parse(time.DateOnly, p.Normalized.RateDate)
+	if err != nil {
+		return err
+	}
+	p.NormalizedCurrency = p.Normalized.Amount.Currency
+	p.RateDate = &rateDate
+	p.AmountNormalized = p.Normalized.Amount.Amount
+	p.DeliveryCostNormalized = p.Normalized.DeliveryCost.Amount
+	p.GoodsTotalNormalized = p.Normalized.GoodsTotal.Amount
+	p.CustomFeeNormalized = p.Normalized.CustomFee.Amount
+	return nil
+}
+
+// AfterFind собирает Normalized и единицу PaymentDt из столбцов
+func (p *Payment) AfterFind(tx *gorm.DB) error {
+	p.PaymentDt.Millis = p.PaymentDtMillis
+	if p.NormalizedCurrency == "" || p.RateDate == nil {
+		p.Normalized = nil
+		return nil
+	}
+
+	cur := p.NormalizedCurrency
+	p.Normalized = &NormalizedPayment{
+		RateDate:     p.RateDate.Format(time.DateOnly),
+		Amount:       money.Money{Amount: p.AmountNormalized, Currency: cur},
+		DeliveryCost: money.Money{Amount: p.DeliveryCostNormalized, Currency: cur},
+		GoodsTotal:   money.Money{Amount: p.GoodsTotalNormalized, Currency: cur},
+		CustomFee:    money.Money{Amount: p.CustomFeeNormalized, Currency: cur},
+	}
+	return nil
+}
+
 // Item — товар в заказе
 type Item struct {
 	ChrtID      int64  `json:"chrt_id" gorm:"primaryKey"`
@@ -78,4 +175,19 @@ type Item struct {
 	NMID        int64  `json:"nm_id" gorm:"not null"`
 	Brand       string `json:"brand" gorm:"size:64;not null"`
 	Status      int    `json:"status" gorm:"not null"`
+
+	// Дата заказа — ключ секционирования items, в API не отдаётся
+	DateCreated Timestamp `json:"-" gorm:"primaryKey;not null"`
+}
+
+// OrderKey — глобальный индекс заказов: уникальность order_uid и track_number
+// поверх секционированной orders и дата создания для отсечения секций
+type OrderKey struct {
+	OrderUID    string    `gorm:"type:uuid;primaryKey"`
+	TrackNumber string    `gorm:"size:64;uniqueIndex;not null"`
+	DateCreated Timestamp `gorm:"not null"`
+}
+
+func (OrderKey) TableName() string {
+	return "order_keys"
 }
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы событий заказа
+const (
+	EventOrderCreated = "order.created"
+	EventOrderUpdated = "order.updated"
+	EventOrderDeleted = "order.deleted"
+	EventOrderPurged  = "order.purged"
+)
+
+// OutboxEvent — событие, ожидающее публикации в Kafka
+type OutboxEvent struct {
+	ID          int64           `gorm:"primaryKey"`
+	EventType   string          `gorm:"size:64;not null"`
+	AggregateID string          `gorm:"type:uuid;not null"`
+	Payload     json.RawMessage `gorm:"type:jsonb;not null"`
+	Attempts    int             `gorm:"not null;default:0"`
+	LastError   string          `gorm:"not null;default:''"`
+	CreatedAt   time.Time
+	SentAt      *time.Time
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
+
+// OrderEvent — тело события, публикуемого в Kafka
+type OrderEvent struct {
+	EventType  string    `json:"event_type"`
+	OrderUID   string    `json:"order_uid"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Order      *Order    `json:"order,omitempty"`
+}
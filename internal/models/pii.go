@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+)
+
+// MapPII возвращает копию заказа, в которой персональные данные (поля с
+// serializer:pii) заменены на fn(value). Исходный заказ не меняется.
+func (o *Order) MapPII(fn func(string) (string, error)) (*Order, error) {
+	if o == nil {
+		return nil, nil
+	}
+	out := *o
+	var err error
+	if out.InternalSignature, err = fn(o.InternalSignature); err != nil {
+		return nil, err
+	}
+	if o.Delivery != nil {
+		d := *o.Delivery
+		for _, field := range []*string{&d.Name, &d.Phone, &d.Address, &d.Email} {
+			if *field, err = fn(*field); err != nil {
+				return nil, err
+			}
+		}
+		out.Delivery = &d
+	}
+	return &out, nil
+}
+
+// SealPII — копия заказа с персональными данными, зашифрованными активным ключом
+// (pii.SetKeyring), для JSON вне столбцов БД: событий outbox и архива
+func (o *Order) SealPII() (*Order, error) {
+	return o.MapPII(pii.Seal)
+}
+
+// OpenPII — копия заказа с расшифрованными персональными данными
+func (o *Order) OpenPII() (*Order, error) {
+	return o.MapPII(pii.Open)
+}
+
+// MapSnapshotPII заменяет персональные данные заказа на fn(value) в JSON-документе
+// вида {"order": {...}, ...} — событии outbox или снимке архива. Остальное
+// содержимое документа не разбирается; без изменений возвращается исходный doc.
+func MapSnapshotPII(doc []byte, fn func(string) (string, error)) ([]byte, error) {
+	var snapshot map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &snapshot); err != nil {
+		return nil, err
+	}
+	var order map[string]json.RawMessage
+	if raw, ok := snapshot["order"]; !ok || json.Unmarshal(raw, &order) != nil || order == nil {
+		return doc, nil // событие без заказа (order.purged)
+	}
+
+	changed := false
+	mapFields := func(obj map[string]json.RawMessage, names ...string) error {
+		for _, name := range names {
+			var value string
+			if raw, ok := obj[name]; !ok || json.Unmarshal(raw, &value) != nil {
+				continue
+			}
+			mapped, err := fn(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			if mapped != value {
+				obj[name], _ = json.Marshal(mapped)
+				changed = true
+			}
+		}
+		return nil
+	}
+
+	if err := mapFields(order, "internal_signature"); err != nil {
+		return nil, err
+	}
+	var delivery map[string]json.RawMessage
+	if raw, ok := order["delivery"]; ok && json.Unmarshal(raw, &delivery) == nil && delivery != nil {
+		if err := mapFields(delivery, "name", "phone", "address", "email"); err != nil {
+			return nil, fmt.Errorf("delivery.%w", err)
+		}
+		order["delivery"], _ = json.Marshal(delivery)
+	}
+	if !changed {
+		return doc, nil
+	}
+	snapshot["order"], _ = json.Marshal(order)
+	return json.Marshal(snapshot)
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// DailyRollup — итоги продаж за день по службе доставки, провайдеру и валюте
+type DailyRollup struct {
+	Day             time.Time `gorm:"type:date;primaryKey"`
+	DeliveryService string    `gorm:"size:64;primaryKey"`
+	Provider        string    `gorm:"size:32;primaryKey"`
+	Currency        string    `gorm:"size:10;primaryKey"`
+	Orders          int64     `gorm:"not null;default:0"`
+	Revenue         int64     `gorm:"not null;default:0"`
+	// RevenueNormalized — выручка в валюте отчётности, минимальные единицы
+	RevenueNormalized int64 `gorm:"not null;default:0"`
+	// Unconverted — заказы без суммы в валюте отчётности (нет курса на дату)
+	Unconverted int64 `gorm:"not null;default:0"`
+}
+
+func (DailyRollup) TableName() string {
+	return "order_rollups_daily"
+}
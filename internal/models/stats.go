@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/money"
+)
+
+// Разрезы статистики заказов
+const (
+	StatsByDay             = "day"
+	StatsByDeliveryService = "delivery_service"
+	StatsByProvider        = "provider"
+	StatsByBank            = "bank"
+	StatsByCurrency        = "currency"
+	StatsByRegion          = "region"
+	StatsByCity            = "city"
+	StatsByBrand           = "brand"
+	StatsByNMID            = "nm_id"
+)
+
+// StatsBucket — число заказов и выручка по одному значению разреза.
+// Для разрезов по товарам (brand, nm_id) выручка — сумма items.total_price,
+// для остальных — payment.amount. Без фильтра по валюте выручка считается
+// в валюте отчётности по пересчитанным суммам; заказы, для которых пересчёта
+// нет, в неё не входят и учитываются в Unconverted.
+type StatsBucket struct {
+	Key         string      `json:"key"`
+	Orders      int64       `json:"orders"`
+	Items       int64       `json:"items,omitempty"`
+	Revenue     money.Money `json:"revenue"`
+	Unconverted int64       `json:"unconverted,omitempty"`
+}
+
+// StatsSummary — итоги за период
+type StatsSummary struct {
+	From        time.Time   `json:"from"`
+	To          time.Time   `json:"to"`
+	Orders      int64       `json:"orders"`
+	Revenue     money.Money `json:"revenue"`
+	Unconverted int64       `json:"unconverted,omitempty"`
+}
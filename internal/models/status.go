@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// OrderStatus — статус заказа в жизненном цикле
+type OrderStatus string
+
+const (
+	StatusCreated    OrderStatus = "created"
+	StatusPaid       OrderStatus = "paid"
+	StatusAssembling OrderStatus = "assembling"
+	StatusShipped    OrderStatus = "shipped"
+	StatusDelivered  OrderStatus = "delivered"
+	StatusCancelled  OrderStatus = "cancelled"
+	StatusReturned   OrderStatus = "returned"
+)
+
+// Valid сообщает, известен ли статус
+func (s OrderStatus) Valid() bool {
+	switch s {
+	case StatusCreated, StatusPaid, StatusAssembling, StatusShipped,
+		StatusDelivered, StatusCancelled, StatusReturned:
+		return true
+	}
+	return false
+}
+
+// StatusHistory — запись о смене статуса заказа
+type StatusHistory struct {
+	ID         int64       `json:"-" gorm:"primaryKey"`
+	OrderUID   string      `json:"-" gorm:"type:uuid;index;not null"`
+	FromStatus OrderStatus `json:"from,omitempty" gorm:"size:16;not null;default:''"`
+	ToStatus   OrderStatus `json:"to" gorm:"size:16;not null"`
+	Reason     string      `json:"reason,omitempty" gorm:"not null;default:''"`
+	ChangedAt  time.Time   `json:"changed_at" gorm:"not null"`
+}
+
+func (StatusHistory) TableName() string {
+	return "status_history"
+}
+
+// StatusChange — сообщение о смене статуса из топика статусов
+type StatusChange struct {
+	OrderUID  string      `json:"order_uid"`
+	Status    OrderStatus `json:"status"`
+	Reason    string      `json:"reason"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
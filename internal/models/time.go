@@ -0,0 +1,200 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Postgres хранит timestamptz с точностью до микросекунды; значения приводятся
+// к этой точности сразу при разборе, чтобы заказ, прочитанный из БД, кодировался
+// в JSON так же, как принятый. Смещение timestamptz не хранит: его хранит
+// отдельный столбец (Order.DateCreatedOffset)
+const timePrecision = time.Microsecond
+
+// Unix-время в JSON — не точнее миллисекунд, поэтому UnixTime обрезается
+// до миллисекунд ещё при приёме, а не только при кодировании
+const unixTimePrecision = time.Millisecond
+
+// Числа от unixMillisThreshold и больше считаются миллисекундами:
+// 1e11 секунд — это 5138 год, 1e11 миллисекунд — 1973
+const unixMillisThreshold = 100_000_000_000
+
+// Timestamp — момент времени, в JSON — строка RFC3339 с исходным смещением
+// ("2021-11-26T09:22:19+03:00"). Принимает также Unix-время в секундах или
+// миллисекундах — оно отдаётся в UTC.
+type Timestamp struct {
+	time.Time
+}
+
+// UnixTime — момент времени, в JSON — Unix-время числом в той единице, в которой
+// пришло: секунды или миллисекунды (Millis). Принимает также строку RFC3339 —
+// она отдаётся секундами, а при дробной части секунды — миллисекундами.
+type UnixTime struct {
+	time.Time
+	Millis bool
+}
+
+// NewTimestamp приводит t к точности хранения, сохраняя смещение
+func NewTimestamp(t time.Time) Timestamp {
+	return Timestamp{normalizeTime(t)}
+}
+
+// NewUnixTime приводит t к UTC и точности Unix-времени в JSON
+func NewUnixTime(t time.Time) UnixTime {
+	return UnixTime{Time: normalizeTime(t).UTC().Truncate(unixTimePrecision)}
+}
+
+// Offset — смещение от UTC в секундах
+func (t Timestamp) Offset() int {
+	_, offset := t.Zone()
+	return offset
+}
+
+// WithOffset возвращает тот же момент со смещением offset (секунды от UTC)
+func (t Timestamp) WithOffset(offset int) Timestamp {
+	if t.IsZero() {
+		return t
+	}
+	if offset == 0 {
+		return Timestamp{t.UTC()}
+	}
+	return Timestamp{t.In(time.FixedZone("", offset))}
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(t.Format(time.RFC3339Nano))
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	parsed, _, err := parseTime(data)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+func (t UnixTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("0"), nil
+	}
+	if !t.Millis && t.Nanosecond() == 0 {
+		return strconv.AppendInt(nil, t.Unix(), 10), nil
+	}
+	return strconv.AppendInt(nil, t.UnixMilli(), 10), nil
+}
+
+func (t *UnixTime) UnmarshalJSON(data []byte) error {
+	parsed, millis, err := parseTime(data)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed.UTC().Truncate(unixTimePrecision)
+	t.Millis = millis
+	return nil
+}
+
+// parseTime разбирает JSON-значение времени: строку RFC3339 (смещение сохраняется)
+// или Unix-время (число или строка из цифр, в UTC); millis — Unix-время пришло
+// в миллисекундах. Пустая строка, null и 0 дают нулевое время.
+func parseTime(data []byte) (t time.Time, millis bool, err error) {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return time.Time{}, false, nil
+	}
+
+	value := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return time.Time{}, false, err
+		}
+		if value == "" {
+			return time.Time{}, false, nil
+		}
+		if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return normalizeTime(t), false, nil
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid time %s: expected RFC3339 or Unix seconds/milliseconds", data)
+	}
+	switch {
+	case n == 0:
+		return time.Time{}, false, nil
+	case n >= unixMillisThreshold || n <= -unixMillisThreshold:
+		return time.UnixMilli(n).UTC(), true, nil
+	default:
+		return time.Unix(n, 0).UTC(), false, nil
+	}
+}
+
+// normalizeTime обрезает t до точности хранения; смещение сохраняется как
+// фиксированная зона (именованная зона вроде Local в БД не переживёт чтение)
+func normalizeTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+	t = t.Truncate(timePrecision)
+	if _, offset := t.Zone(); offset != 0 {
+		return t.In(time.FixedZone("", offset))
+	}
+	return t.UTC()
+}
+
+// Value и Scan хранят значения в столбцах TIMESTAMPTZ
+
+func (t Timestamp) Value() (driver.Value, error) {
+	return timeValue(t.Time)
+}
+
+func (t *Timestamp) Scan(src any) error {
+	return scanTime(&t.Time, src)
+}
+
+func (Timestamp) GormDataType() string {
+	return "timestamptz"
+}
+
+func (t UnixTime) Value() (driver.Value, error) {
+	return timeValue(t.Time)
+}
+
+func (t *UnixTime) Scan(src any) error {
+	if err := scanTime(&t.Time, src); err != nil {
+		return err
+	}
+	t.Time = t.Truncate(unixTimePrecision)
+	return nil
+}
+
+func (UnixTime) GormDataType() string {
+	return "timestamptz"
+}
+
+func timeValue(t time.Time) (driver.Value, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	return t.UTC(), nil
+}
+
+func scanTime(dst *time.Time, src any) error {
+	switch v := src.(type) {
+	case nil:
+		*dst = time.Time{}
+	case time.Time:
+		*dst = normalizeTime(v).UTC()
+	default:
+		return fmt.Errorf("cannot scan %T into time", src)
+	}
+	return nil
+}
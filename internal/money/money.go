@@ -0,0 +1,109 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// ErrPrecision — у суммы больше знаков после запятой, чем у минимальной единицы валюты
+var ErrPrecision = errors.New("amount exceeds currency minor-unit precision")
+
+// Money — сумма в минимальных единицах валюты (центах, копейках)
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New создаёт сумму из минимальных единиц
+func New(minor int64, code string) (Money, error) {
+	if _, err := LookupCurrency(code); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: minor, Currency: code}, nil
+}
+
+// FromMajor создаёт сумму из целых единиц валюты (долларов, рублей)
+func FromMajor(units int64, code string) (Money, error) {
+	cur, err := LookupCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+	scale := pow10(cur.MinorUnits)
+	if units > math.MaxInt64/scale || units < math.MinInt64/scale {
+		return Money{}, fmt.Errorf("amount %d %s overflows", units, code)
+	}
+	return Money{Amount: units * scale, Currency: code}, nil
+}
+
+// Parse разбирает десятичную сумму ("18.17") и проверяет, что точность
+// не превышает минимальную единицу валюты
+func Parse(decimal, code string) (Money, error) {
+	cur, err := LookupCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(decimal))
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount %q", decimal)
+	}
+	r.Mul(r, new(big.Rat).SetInt64(pow10(cur.MinorUnits)))
+	if !r.IsInt() {
+		return Money{}, fmt.Errorf("%w: %s allows %d decimal places", ErrPrecision, code, cur.MinorUnits)
+	}
+	if !r.Num().IsInt64() {
+		return Money{}, fmt.Errorf("amount %q %s overflows", decimal, code)
+	}
+	return Money{Amount: r.Num().Int64(), Currency: code}, nil
+}
+
+// Decimal возвращает сумму в целых единицах с точностью валюты: "18.17", "1500" для JPY
+func (m Money) Decimal() string {
+	cur, err := LookupCurrency(m.Currency)
+	if err != nil || cur.MinorUnits == 0 {
+		return fmt.Sprintf("%d", m.Amount)
+	}
+	return new(big.Rat).SetFrac64(m.Amount, pow10(cur.MinorUnits)).FloatString(cur.MinorUnits)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON кодирует сумму строкой, чтобы не терять точность во float
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Amount   json.Number `json:"amount"`
+		Currency string      `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	parsed, err := Parse(raw.Amount.String(), raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
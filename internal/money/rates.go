@@ -0,0 +1,162 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+)
+
+// ErrNoRate — в таблице нет курса валюты на нужную дату
+var ErrNoRate = errors.New("no exchange rate")
+
+// RateTable — курсы валют по версиям (датам). Для суммы на момент t берётся
+// последняя версия с датой не позже t.
+type RateTable struct {
+	base     string
+	versions []rateVersion // по возрастанию даты
+}
+
+type rateVersion struct {
+	date  time.Time
+	rates map[string]*big.Rat // цена единицы валюты в base
+}
+
+// Формат файла курсов:
+//
+//	{"base": "RUB", "versions": [
+//	    {"date": "2024-01-01", "rates": {"USD": "89.6883", "EUR": "99.1919"}}
+//	]}
+type rateFile struct {
+	Base     string `json:"base"`
+	Versions []struct {
+		Date  string            `json:"date"`
+		Rates map[string]string `json:"rates"`
+	} `json:"versions"`
+}
+
+// LoadRates читает таблицу курсов из JSON-файла
+func LoadRates(path string) (*RateTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseRates(f)
+}
+
+// ParseRates разбирает таблицу курсов и проверяет коды валют и значения
+func ParseRates(r io.Reader) (*RateTable, error) {
+	var file rateFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("decode rates: %w", err)
+	}
+	if _, err := LookupCurrency(file.Base); err != nil {
+		return nil, fmt.Errorf("rates base: %w", err)
+	}
+
+	table := &RateTable{base: file.Base}
+	for _, v := range file.Versions {
+		date, err := time.Parse(time.DateOnly, v.Date)
+		if err != nil {
+			return nil, fmt.Errorf("rates version %q: %w", v.Date, err)
+		}
+
+		version := rateVersion{date: date, rates: map[string]*big.Rat{file.Base: big.NewRat(1, 1)}}
+		for code, value := range v.Rates {
+			if _, err := LookupCurrency(code); err != nil {
+				return nil, fmt.Errorf("rates version %s: %w", v.Date, err)
+			}
+			rate, ok := new(big.Rat).SetString(value)
+			if !ok || rate.Sign() <= 0 {
+				return nil, fmt.Errorf("rates version %s: invalid rate %q for %s", v.Date, value, code)
+			}
+			version.rates[code] = rate
+		}
+		table.versions = append(table.versions, version)
+	}
+
+	sort.Slice(table.versions, func(i, j int) bool {
+		return table.versions[i].date.Before(table.versions[j].date)
+	})
+	return table, nil
+}
+
+// Base — валюта, в которой заданы курсы
+func (t *RateTable) Base() string {
+	return t.base
+}
+
+// Convert пересчитывает сумму в валюту to по курсам, действовавшим на момент at,
+// с банковским округлением до минимальной единицы to. Возвращает также дату версии курсов.
+func (t *RateTable) Convert(m Money, to string, at time.Time) (Money, time.Time, error) {
+	src, err := LookupCurrency(m.Currency)
+	if err != nil {
+		return Money{}, time.Time{}, err
+	}
+	dst, err := LookupCurrency(to)
+	if err != nil {
+		return Money{}, time.Time{}, err
+	}
+
+	version, ok := t.versionAt(at)
+	if !ok {
+		return Money{}, time.Time{}, fmt.Errorf("%w on %s", ErrNoRate, at.Format(time.DateOnly))
+	}
+	srcRate, ok := version.rates[src.Code]
+	if !ok {
+		return Money{}, time.Time{}, fmt.Errorf("%w for %s on %s", ErrNoRate, src.Code, version.date.Format(time.DateOnly))
+	}
+	dstRate, ok := version.rates[dst.Code]
+	if !ok {
+		return Money{}, time.Time{}, fmt.Errorf("%w for %s on %s", ErrNoRate, dst.Code, version.date.Format(time.DateOnly))
+	}
+
+	// amount / 10^src * srcRate / dstRate * 10^dst
+	v := new(big.Rat).SetFrac64(m.Amount, pow10(src.MinorUnits))
+	v.Mul(v, srcRate)
+	v.Quo(v, dstRate)
+	v.Mul(v, new(big.Rat).SetInt64(pow10(dst.MinorUnits)))
+
+	minor := roundHalfEven(v)
+	if !minor.IsInt64() {
+		return Money{}, time.Time{}, fmt.Errorf("converted amount %s overflows", m)
+	}
+	return Money{Amount: minor.Int64(), Currency: dst.Code}, version.date, nil
+}
+
+func (t *RateTable) versionAt(at time.Time) (rateVersion, bool) {
+	day := at.UTC().Truncate(24 * time.Hour)
+	i := sort.Search(len(t.versions), func(i int) bool {
+		return t.versions[i].date.After(day)
+	})
+	if i == 0 {
+		return rateVersion{}, false
+	}
+	return t.versions[i-1], true
+}
+
+// roundHalfEven округляет до целого, половину — к чётному
+func roundHalfEven(r *big.Rat) *big.Int {
+	quo, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return quo
+	}
+
+	// Сравниваем 2*|rem| с denom
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	cmp := twice.Cmp(r.Denom())
+	if cmp > 0 || (cmp == 0 && quo.Bit(0) == 1) {
+		if r.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo
+}
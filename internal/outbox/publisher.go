@@ -0,0 +1,49 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/segmentio/kafka-go"
+)
+
+// Publisher отправляет события во внешнюю шину
+type Publisher interface {
+	Publish(ctx context.Context, events []models.OutboxEvent) error
+	Close() error
+}
+
+// KafkaPublisher публикует события в топик Kafka; ключ сообщения — order_uid,
+// поэтому события одного заказа попадают в одну партицию и сохраняют порядок
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(broker, topic string) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(broker),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		},
+	}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, events []models.OutboxEvent) error {
+	msgs := make([]kafka.Message, len(events))
+	for i, e := range events {
+		msgs[i] = kafka.Message{
+			Key:   []byte(e.AggregateID),
+			Value: e.Payload,
+			Headers: []kafka.Header{
+				{Key: "event_type", Value: []byte(e.EventType)},
+			},
+		}
+	}
+	return p.writer.WriteMessages(ctx, msgs...)
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,95 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+)
+
+// Relay переносит события из таблицы outbox в Publisher (at-least-once)
+type Relay struct {
+	repo      repository.OutboxRepositoryInterface
+	publisher Publisher
+	interval  time.Duration
+	retention time.Duration
+	batchSize int
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewRelay(repo repository.OutboxRepositoryInterface, publisher Publisher, interval, retention time.Duration) *Relay {
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+		interval:  interval,
+		retention: retention,
+		batchSize: 100,
+		stop:      make(chan struct{}),
+	}
+}
+
+func (r *Relay) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		cleanup := time.NewTicker(time.Hour)
+		defer cleanup.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				r.Flush()
+			case <-cleanup.C:
+				r.Cleanup()
+			}
+		}
+	}()
+}
+
+// Flush публикует все накопившиеся события, пока очередь не опустеет
+func (r *Relay) Flush() {
+	for {
+		n, err := r.repo.ProcessPending(r.batchSize, r.publish)
+		if err != nil {
+			log.Printf("❌ Outbox relay: %v", err)
+			return
+		}
+		if n < r.batchSize {
+			return
+		}
+	}
+}
+
+// Cleanup удаляет отправленные события старше срока хранения
+func (r *Relay) Cleanup() {
+	deleted, err := r.repo.DeleteSentBefore(time.Now().Add(-r.retention))
+	if err != nil {
+		log.Printf("❌ Outbox cleanup: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("🧹 Outbox: удалено отправленных событий: %d", deleted)
+	}
+}
+
+func (r *Relay) publish(events []models.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return r.publisher.Publish(ctx, events)
+}
+
+func (r *Relay) Close() error {
+	close(r.stop)
+	r.wg.Wait()
+	return r.publisher.Close()
+}
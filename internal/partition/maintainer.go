@@ -0,0 +1,92 @@
+package partition
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+)
+
+// Maintainer заранее создаёт помесячные секции orders и items и
+// разбирает DEFAULT-секцию по месяцам
+type Maintainer struct {
+	repo     repository.PartitionRepositoryInterface
+	ahead    int
+	interval time.Duration
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewMaintainer(repo repository.PartitionRepositoryInterface, monthsAhead int, interval time.Duration) *Maintainer {
+	return &Maintainer{
+		repo:     repo,
+		ahead:    monthsAhead,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+func (m *Maintainer) Start() {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := m.RunOnce(time.Now()); err != nil {
+				log.Printf("❌ Partitions: %v", err)
+			}
+
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce создаёт секции с прошлого месяца по now+ahead и для всех месяцев,
+// застрявших в DEFAULT-секции
+func (m *Maintainer) RunOnce(now time.Time) error {
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	for _, table := range []string{"orders", "items"} {
+		partitioned, err := m.repo.IsPartitioned(table)
+		if err != nil {
+			return err
+		}
+		if !partitioned {
+			continue
+		}
+
+		months, err := m.repo.DefaultMonths(table)
+		if err != nil {
+			return err
+		}
+		for i := -1; i <= m.ahead; i++ {
+			months = append(months, current.AddDate(0, i, 0))
+		}
+
+		for _, month := range months {
+			created, err := m.repo.EnsureMonth(table, month)
+			if err != nil {
+				return err
+			}
+			if created {
+				log.Printf("🗂️  Partitions: создана секция %s за %s", table, month.Format("2006-01"))
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Maintainer) Close() error {
+	close(m.stop)
+	m.wg.Wait()
+	return nil
+}
@@ -0,0 +1,18 @@
+package pii
+
+import "context"
+
+type roleKey struct{}
+
+// WithRole сохраняет роль вызывающего в контексте запроса
+func WithRole(ctx context.Context, role Role) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// RoleFrom возвращает роль из контекста; без роли — RolePublic
+func RoleFrom(ctx context.Context) Role {
+	if role, ok := ctx.Value(roleKey{}).(Role); ok {
+		return role
+	}
+	return RolePublic
+}
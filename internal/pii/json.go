@@ -0,0 +1,45 @@
+package pii
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// MaskJSON маскирует строковые поля JSON-документа по политике. Элементы
+// массивов адресуются путём без индекса ("items.name"). Документ, который
+// не разбирается как JSON, целиком заменяется описанием, чтобы не попасть в журнал.
+func MaskJSON(data []byte, policy Policy) []byte {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return []byte(fmt.Sprintf("<%d bytes, not JSON>", len(data)))
+	}
+
+	masked, err := json.Marshal(maskValue(doc, "", policy))
+	if err != nil {
+		return []byte(fmt.Sprintf("<%d bytes>", len(data)))
+	}
+	return masked
+}
+
+func maskValue(v any, path string, policy Policy) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			v[key] = maskValue(child, childPath, policy)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = maskValue(child, path, policy)
+		}
+	case string:
+		return policy.Apply(path, v)
+	}
+	return v
+}
@@ -0,0 +1,330 @@
+package pii
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Форматы зашифрованных значений:
+//
+//	enc:v1:base64(nonce || ciphertext)                               — один ключ (LegacyKeyID)
+//	enc:v2:base64(len(kid) || kid || wrapped DEK || nonce || ciphertext) — конверт
+//
+// В формате v2 значение шифруется случайным ключом данных (DEK), а DEK — ключом
+// из keyfile (KEK) с идентификатором kid. При ротации перешифровывается только DEK.
+const (
+	legacyPrefix   = "enc:v1:"
+	envelopePrefix = "enc:v2:"
+
+	// LegacyKeyID — ключ, которым зашифрованы значения enc:v1 (PII_ENCRYPTION_KEY)
+	LegacyKeyID = "legacy"
+
+	keySize = 32
+)
+
+var (
+	// ErrNoKey — в БД или кеше зашифрованное значение, а ключи не настроены
+	ErrNoKey = errors.New("PII is encrypted but no encryption key is configured")
+	// ErrUnknownKey — значение зашифровано ключом, которого нет в keyfile
+	ErrUnknownKey = errors.New("unknown PII key id")
+)
+
+// Keyring — набор ключей шифрования PII; новые значения шифруются активным ключом,
+// остальные нужны для чтения значений, ещё не перешифрованных после ротации
+type Keyring struct {
+	active string
+	keys   map[string]cipher.AEAD
+}
+
+// keyFile — формат keyfile: {"active": "2024-06", "keys": {"2024-01": "base64", "2024-06": "base64"}}
+type keyFile struct {
+	Active string            `json:"active"`
+	Keys   map[string]string `json:"keys"`
+}
+
+// LoadKeyring читает ключи из keyfile
+func LoadKeyring(path string) (*Keyring, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseKeyring(f)
+}
+
+func ParseKeyring(r io.Reader) (*Keyring, error) {
+	var file keyFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("decode keyfile: %w", err)
+	}
+
+	keys := make(map[string][]byte, len(file.Keys))
+	for id, encoded := range file.Keys {
+		key, err := ParseKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return NewKeyring(file.Active, keys)
+}
+
+// OpenKeyring собирает ключи из настроек: keyfile или, без него, один ключ
+// PII_ENCRYPTION_KEY с идентификатором LegacyKeyID. Без того и другого — nil.
+func OpenKeyring(keyfile, legacyKey string) (*Keyring, error) {
+	switch {
+	case keyfile != "":
+		return LoadKeyring(keyfile)
+	case legacyKey != "":
+		key, err := ParseKey(legacyKey)
+		if err != nil {
+			return nil, err
+		}
+		return NewKeyring(LegacyKeyID, map[string][]byte{LegacyKeyID: key})
+	}
+	return nil, nil
+}
+
+func NewKeyring(active string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[active]; !ok {
+		return nil, fmt.Errorf("active key %q is not in the keyring", active)
+	}
+
+	k := &Keyring{active: active, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" || len(id) > 255 {
+			return nil, fmt.Errorf("key id %q must be 1-255 bytes", id)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		k.keys[id] = aead
+	}
+	return k, nil
+}
+
+// ParseKey декодирует ключ из base64 (32 байта)
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("decode key: %w", err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+// Active возвращает идентификатор ключа, которым шифруются новые значения
+func (k *Keyring) Active() string {
+	return k.active
+}
+
+// Encrypt шифрует значение в конверте под активным ключом
+func (k *Keyring) Encrypt(plain string) (string, error) {
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+	sealed, err := seal(dek, []byte(plain), nil)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := k.wrap(k.active, dek)
+	if err != nil {
+		return "", err
+	}
+	return envelope{kid: k.active, wrapped: wrapped, sealed: sealed}.String(), nil
+}
+
+// Decrypt расшифровывает значение; незашифрованное возвращается как есть
+func (k *Keyring) Decrypt(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, envelopePrefix):
+		env, err := parseEnvelope(value)
+		if err != nil {
+			return "", err
+		}
+		dek, err := k.unwrap(env.kid, env.wrapped)
+		if err != nil {
+			return "", err
+		}
+		plain, err := open(dek, env.sealed, nil)
+		if err != nil {
+			return "", fmt.Errorf("decrypt PII: %w", err)
+		}
+		return string(plain), nil
+
+	case strings.HasPrefix(value, legacyPrefix):
+		aead, ok := k.keys[LegacyKeyID]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnknownKey, LegacyKeyID)
+		}
+		sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, legacyPrefix))
+		if err != nil {
+			return "", fmt.Errorf("decrypt PII: %w", err)
+		}
+		plain, err := openWith(aead, sealed, nil)
+		if err != nil {
+			return "", fmt.Errorf("decrypt PII: %w", err)
+		}
+		return string(plain), nil
+	}
+	return value, nil
+}
+
+// Rotate приводит значение к активному ключу. Открытые значения и enc:v1 шифруются
+// заново, у конверта под другим ключом перешифровывается только DEK.
+// changed == false — значение уже зашифровано активным ключом (или пустое).
+func (k *Keyring) Rotate(value string) (rotated string, changed bool, err error) {
+	if value == "" {
+		return "", false, nil
+	}
+	if !strings.HasPrefix(value, envelopePrefix) {
+		plain, err := k.Decrypt(value)
+		if err != nil {
+			return "", false, err
+		}
+		rotated, err := k.Encrypt(plain)
+		return rotated, err == nil, err
+	}
+
+	env, err := parseEnvelope(value)
+	if err != nil {
+		return "", false, err
+	}
+	if env.kid == k.active {
+		return value, false, nil
+	}
+	dek, err := k.unwrap(env.kid, env.wrapped)
+	if err != nil {
+		return "", false, err
+	}
+	if env.wrapped, err = k.wrap(k.active, dek); err != nil {
+		return "", false, err
+	}
+	env.kid = k.active
+	return env.String(), true, nil
+}
+
+// KeyID возвращает идентификатор ключа, которым зашифровано значение
+func KeyID(value string) (string, bool) {
+	switch {
+	case strings.HasPrefix(value, envelopePrefix):
+		env, err := parseEnvelope(value)
+		return env.kid, err == nil
+	case strings.HasPrefix(value, legacyPrefix):
+		return LegacyKeyID, true
+	}
+	return "", false
+}
+
+// IsEncrypted сообщает, зашифровано ли значение
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix) || strings.HasPrefix(value, legacyPrefix)
+}
+
+// wrap шифрует DEK ключом kid; kid связан с шифротекстом как дополнительные данные
+func (k *Keyring) wrap(kid string, dek []byte) ([]byte, error) {
+	aead, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+	return sealWith(aead, dek, []byte(kid))
+}
+
+func (k *Keyring) unwrap(kid string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+	dek, err := openWith(aead, wrapped, []byte(kid))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	return dek, nil
+}
+
+type envelope struct {
+	kid     string
+	wrapped []byte // nonce || DEK, зашифрованный KEK
+	sealed  []byte // nonce || значение, зашифрованное DEK
+}
+
+// Длина обёрнутого DEK: nonce GCM + ключ + тег
+const wrappedSize = 12 + keySize + 16
+
+func (e envelope) String() string {
+	buf := make([]byte, 0, 1+len(e.kid)+len(e.wrapped)+len(e.sealed))
+	buf = append(buf, byte(len(e.kid)))
+	buf = append(buf, e.kid...)
+	buf = append(buf, e.wrapped...)
+	buf = append(buf, e.sealed...)
+	return envelopePrefix + base64.StdEncoding.EncodeToString(buf)
+}
+
+func parseEnvelope(value string) (envelope, error) {
+	buf, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, envelopePrefix))
+	if err != nil {
+		return envelope{}, fmt.Errorf("decode PII envelope: %w", err)
+	}
+	if len(buf) < 1 || len(buf) < 1+int(buf[0])+wrappedSize {
+		return envelope{}, errors.New("decode PII envelope: value too short")
+	}
+	kidEnd := 1 + int(buf[0])
+	return envelope{
+		kid:     string(buf[1:kidEnd]),
+		wrapped: buf[kidEnd : kidEnd+wrappedSize],
+		sealed:  buf[kidEnd+wrappedSize:],
+	}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(key, plain, aad []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return sealWith(aead, plain, aad)
+}
+
+func open(key, sealed, aad []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return openWith(aead, sealed, aad)
+}
+
+// sealWith возвращает nonce || ciphertext
+func sealWith(aead cipher.AEAD, plain, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, aad), nil
+}
+
+func openWith(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
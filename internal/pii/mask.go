@@ -0,0 +1,112 @@
+// Package pii — маскирование персональных данных по ролям и их шифрование при хранении
+package pii
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Mask скрывает часть значения; пустое значение остаётся пустым
+type Mask func(string) string
+
+// MaskPhone оставляет код страны и последние цифры: "+972****000"
+func MaskPhone(s string) string {
+	if s == "" {
+		return ""
+	}
+	r := []rune(s)
+	if len(r) < 8 {
+		return "****"
+	}
+	return string(r[:4]) + "****" + string(r[len(r)-3:])
+}
+
+// MaskEmail оставляет первую букву имени и домен: "t***@gmail.com"
+func MaskEmail(s string) string {
+	at := strings.LastIndexByte(s, '@')
+	if at <= 0 {
+		return MaskAll(s)
+	}
+	first, _ := utf8.DecodeRuneInString(s)
+	return string(first) + "***" + s[at:]
+}
+
+// MaskName оставляет первые буквы слов: "T*** T***"
+func MaskName(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		first, _ := utf8.DecodeRuneInString(w)
+		words[i] = string(first) + "***"
+	}
+	return strings.Join(words, " ")
+}
+
+// MaskAll скрывает значение целиком
+func MaskAll(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "***"
+}
+
+// Role — роль вызывающего, от неё зависит, какие поля маскируются
+type Role string
+
+const (
+	RoleAdmin   Role = "admin"   // видит всё
+	RoleSupport Role = "support" // видит имя и адрес, контакты маскированы
+	RolePublic  Role = "public"  // персональные данные и реквизиты оплаты маскированы
+)
+
+// ParseRole разбирает имя роли
+func ParseRole(s string) (Role, error) {
+	switch role := Role(strings.ToLower(strings.TrimSpace(s))); role {
+	case RoleAdmin, RoleSupport, RolePublic:
+		return role, nil
+	}
+	return "", fmt.Errorf("unknown role %q", s)
+}
+
+// Policy сопоставляет поле заказа (путь в JSON API, "delivery.phone") с маской
+type Policy map[string]Mask
+
+// Apply маскирует значение поля, если политика его затрагивает
+func (p Policy) Apply(field, value string) string {
+	if mask, ok := p[field]; ok {
+		return mask(value)
+	}
+	return value
+}
+
+var policies = map[Role]Policy{
+	RoleAdmin: {},
+	RoleSupport: {
+		"internal_signature": MaskAll,
+		"delivery.phone":     MaskPhone,
+		"delivery.email":     MaskEmail,
+	},
+	RolePublic: {
+		"internal_signature": MaskAll,
+		"delivery.name":      MaskName,
+		"delivery.phone":     MaskPhone,
+		"delivery.email":     MaskEmail,
+		"delivery.address":   MaskAll,
+		"delivery.zip":       MaskAll,
+		"payment.request_id": MaskAll,
+		"payment.bank":       MaskAll,
+	},
+}
+
+// PolicyFor возвращает политику роли; для неизвестной роли — самую строгую
+func PolicyFor(role Role) Policy {
+	if p, ok := policies[role]; ok {
+		return p
+	}
+	return policies[RolePublic]
+}
+
+// LogPolicy — политика для журналов: маскируется всё, что скрыто от public
+func LogPolicy() Policy {
+	return policies[RolePublic]
+}
@@ -0,0 +1,70 @@
+package pii
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"gorm.io/gorm/schema"
+)
+
+// active — ключи для столбцов с serializer:pii; nil — значения пишутся открытыми
+var active atomic.Pointer[Keyring]
+
+// SetKeyring включает (или, с nil, выключает) шифрование PII при записи
+func SetKeyring(k *Keyring) {
+	active.Store(k)
+}
+
+// Seal шифрует значение активным ключом; без ключей или для пустого значения
+// возвращает его как есть
+func Seal(value string) (string, error) {
+	if k := active.Load(); k != nil && value != "" {
+		return k.Encrypt(value)
+	}
+	return value, nil
+}
+
+// Open расшифровывает значение; открытое возвращает как есть.
+// Зашифрованное значение без настроенных ключей — ErrNoKey.
+func Open(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	k := active.Load()
+	if k == nil {
+		return "", ErrNoKey
+	}
+	return k.Decrypt(value)
+}
+
+// Serializer — GORM-сериализатор строковых столбцов с персональными данными
+// (тег serializer:pii). Пишет значения зашифрованными активным ключом, если
+// ключи настроены, и расшифровывает при чтении; открытые значения читаются как есть.
+type Serializer struct{}
+
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("unsupported PII column value %T", dbValue)
+	}
+
+	value, err := Open(value)
+	if err != nil {
+		return err
+	}
+	field.ReflectValueOf(ctx, dst).SetString(value)
+	return nil
+}
+
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue any) (any, error) {
+	value, _ := fieldValue.(string)
+	return Seal(value)
+}
@@ -0,0 +1,172 @@
+// Package ratelimit — ограничение частоты запросов по алгоритму token bucket:
+// у каждого клиента в каждом классе маршрутов своё ведро на Burst токенов,
+// которое пополняется со скоростью Rate токенов в секунду.
+package ratelimit
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Limit — скорость пополнения (токенов в секунду) и ёмкость ведра
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited сообщает, что класс маршрутов не ограничен
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Window — за сколько пустое ведро наполняется полностью
+func (l Limit) Window() time.Duration {
+	return seconds(float64(l.Burst) / l.Rate)
+}
+
+// ParseLimit разбирает лимит вида "<число>/<s|m|h>[:<burst>]", например "100/m:20";
+// без burst ёмкость ведра равна числу запросов за период. "0" — без ограничения.
+func ParseLimit(s string) (Limit, error) {
+	if s == "0" || s == "off" {
+		return Limit{}, nil
+	}
+	spec, burstSpec, hasBurst := strings.Cut(s, ":")
+	countSpec, period, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("limit %q: want <count>/<s|m|h>[:<burst>]", s)
+	}
+	count, err := strconv.Atoi(countSpec)
+	if err != nil || count <= 0 {
+		return Limit{}, fmt.Errorf("limit %q: invalid count", s)
+	}
+
+	var per time.Duration
+	switch period {
+	case "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("limit %q: period must be s, m or h", s)
+	}
+
+	limit := Limit{Rate: float64(count) / per.Seconds(), Burst: count}
+	if hasBurst {
+		if limit.Burst, err = strconv.Atoi(burstSpec); err != nil || limit.Burst <= 0 {
+			return Limit{}, fmt.Errorf("limit %q: invalid burst", s)
+		}
+	}
+	return limit, nil
+}
+
+// ParseLimits разбирает лимиты классов маршрутов: "read=20/s:40,write=50/s"
+func ParseLimits(entries []string) (map[string]Limit, error) {
+	limits := make(map[string]Limit, len(entries))
+	for _, entry := range entries {
+		class, spec, ok := strings.Cut(entry, "=")
+		if !ok || class == "" {
+			return nil, fmt.Errorf("rate limit %q: want <class>=<limit>", entry)
+		}
+		limit, err := ParseLimit(spec)
+		if err != nil {
+			return nil, err
+		}
+		limits[class] = limit
+	}
+	return limits, nil
+}
+
+// Result — итог попытки взять токен
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter — через сколько появится токен (для отказа)
+	RetryAfter time.Duration
+	// Reset — через сколько ведро наполнится полностью
+	Reset time.Duration
+}
+
+// Store хранит вёдра клиентов
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+var metrics = expvar.NewMap("ratelimit")
+
+// Limiter применяет лимиты классов маршрутов к клиентам
+type Limiter struct {
+	store  Store
+	limits map[string]Limit
+	// local считает лимиты, пока общее хранилище недоступно
+	local    *MemoryStore
+	degraded atomic.Bool
+}
+
+// New создаёт ограничитель; классы без лимита в limits не ограничиваются
+func New(store Store, limits map[string]Limit) *Limiter {
+	return &Limiter{store: store, limits: limits, local: NewMemoryStore()}
+}
+
+// Limit возвращает лимит класса маршрутов
+func (l *Limiter) Limit(class string) Limit {
+	return l.limits[class]
+}
+
+// Take берёт токен клиента client в классе class. Если общее хранилище
+// недоступно, лимиты временно считаются в памяти реплики: ограничение не должно
+// ни ронять API вместе с Redis, ни пропадать.
+func (l *Limiter) Take(ctx context.Context, class, client string) Result {
+	limit := l.limits[class]
+	if limit.Unlimited() {
+		return Result{Allowed: true}
+	}
+
+	key := class + ":" + client
+	res, err := l.store.Take(ctx, key, limit)
+	if err != nil {
+		metrics.Add("store_errors", 1)
+		if !l.degraded.Swap(true) {
+			log.Printf("⚠️ Rate limit store failed, counting limits locally: %v", err)
+		}
+		res, _ = l.local.Take(ctx, key, limit)
+	} else if l.degraded.Swap(false) {
+		log.Printf("✅ Rate limit store recovered")
+	}
+	if res.Allowed {
+		metrics.Add("allowed."+class, 1)
+	} else {
+		metrics.Add("throttled."+class, 1)
+	}
+	return res
+}
+
+// refill — арифметика ведра (в Redis то же считает takeScript): сколько токенов
+// стало через elapsed после состояния tokens и можно ли взять один
+func refill(limit Limit, tokens float64, elapsed time.Duration) (float64, Result) {
+	burst := float64(limit.Burst)
+	tokens = math.Min(burst, tokens+elapsed.Seconds()*limit.Rate)
+
+	var res Result
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	res.Remaining = int(tokens)
+	res.Reset = seconds((burst - tokens) / limit.Rate)
+	return tokens, res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
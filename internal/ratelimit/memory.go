@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Как часто удалять вёдра, которые успели наполниться и стали не нужны
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+	full   time.Time // когда ведро наполнится; после этого его можно забыть
+}
+
+// MemoryStore — вёдра в памяти процесса; у каждой реплики свои лимиты
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// WithClock подменяет часы (для тестов)
+func (s *MemoryStore) WithClock(now func() time.Time) *MemoryStore {
+	s.now = now
+	return s
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), at: now}
+		s.buckets[key] = b
+	}
+	tokens, res := refill(limit, b.tokens, now.Sub(b.at))
+	b.tokens, b.at, b.full = tokens, now, now.Add(res.Reset)
+	return res, nil
+}
+
+// sweep удаляет полные вёдра: новое ведро для того же клиента будет таким же
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Len возвращает число отслеживаемых вёдер
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
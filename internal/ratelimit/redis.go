@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// takeScript атомарно пополняет ведро и берёт токен. Время берётся у Redis,
+// чтобы расхождение часов реплик не влияло на лимиты.
+// KEYS[1] — ведро; ARGV: rate (токенов в секунду), burst.
+// Возвращает {allowed, tokens * 1000}.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(state[1]) or burst
+local at = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - at) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, math.floor(tokens * 1000)}
+`)
+
+// RedisStore — вёдра в Redis, общие для всех реплик
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(addr, password string) *RedisStore {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       0,
+	})
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	reply, err := takeScript.Run(ctx, s.client, []string{"ratelimit:" + key},
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64), limit.Burst).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	tokens := float64(reply[1]) / 1000
+	res := Result{Allowed: reply[0] == 1, Remaining: int(tokens)}
+	res.Reset = seconds((float64(limit.Burst) - tokens) / limit.Rate)
+	if !res.Allowed {
+		res.RetryAfter = seconds(math.Max(0, 1-tokens) / limit.Rate)
+	}
+	return res, nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
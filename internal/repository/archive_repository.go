@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ArchiveRepositoryInterface interface {
+	// ArchiveBefore блокирует до limit заказов, созданных раньше before, передаёт их в store
+	// и в той же транзакции записывает возвращённые строки архива и удаляет оригиналы.
+	ArchiveBefore(before time.Time, limit int, store func([]models.ArchivedOrder) ([]models.ArchiveRecord, error)) (int, error)
+	FindArchived(orderUID string) (*models.ArchiveRecord, error)
+}
+
+type ArchiveRepository struct {
+	db          *gorm.DB
+	maintenance *gorm.DB
+}
+
+// NewArchiveRepository: db — для поиска в архиве на пути запроса, maintenance —
+// для переноса заказов в архив, без таймаута запросов
+func NewArchiveRepository(db, maintenance *gorm.DB) ArchiveRepositoryInterface {
+	return &ArchiveRepository{db: db, maintenance: maintenance}
+}
+
+func (r *ArchiveRepository) ArchiveBefore(before time.Time, limit int, store func([]models.ArchivedOrder) ([]models.ArchiveRecord, error)) (int, error) {
+	var archived int
+	err := r.maintenance.Transaction(func(tx *gorm.DB) error {
+		// Unscoped: мягко удалённые заказы тоже уходят из горячих таблиц
+		var orders []models.Order
+		if err := tx.
+			Unscoped().
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Preload("Delivery").
+			Preload("Payment").
+			Preload("Items").
+			Where("date_created < ?", before).
+			Order("date_created").
+			Limit(limit).
+			Find(&orders).Error; err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+
+		uids := make([]string, len(orders))
+		for i, o := range orders {
+			uids[i] = o.OrderUID
+		}
+
+		var history []models.StatusHistory
+		if err := tx.Where("order_uid IN ?", uids).Order("changed_at, id").Find(&history).Error; err != nil {
+			return err
+		}
+		byOrder := make(map[string][]models.StatusHistory, len(orders))
+		for _, h := range history {
+			byOrder[h.OrderUID] = append(byOrder[h.OrderUID], h)
+		}
+
+		snapshots := make([]models.ArchivedOrder, len(orders))
+		for i := range orders {
+			snapshots[i] = models.ArchivedOrder{Order: &orders[i], History: byOrder[orders[i].OrderUID]}
+		}
+
+		records, err := store(snapshots)
+		if err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&records).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("order_id IN ?", uids).Delete(&models.Item{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_id IN ?", uids).Delete(&models.Payment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_id IN ?", uids).Delete(&models.Delivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_uid IN ?", uids).Delete(&models.StatusHistory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("order_uid IN ?", uids).Delete(&models.Order{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_uid IN ?", uids).Delete(&models.OrderKey{}).Error; err != nil {
+			return err
+		}
+
+		archived = len(orders)
+		return nil
+	})
+	return archived, err
+}
+
+func (r *ArchiveRepository) FindArchived(orderUID string) (*models.ArchiveRecord, error) {
+	var record models.ArchiveRecord
+	if err := r.db.Where("order_uid = ?", orderUID).First(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/breaker"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// BreakerOrderRepository защищает репозиторий предохранителем: при недоступном
+// Postgres вызовы сразу завершаются breaker.ErrOpen, а не копятся в ожидании
+type BreakerOrderRepository struct {
+	inner   OrderRepositoryInterface
+	breaker *breaker.Breaker
+}
+
+func NewBreakerOrderRepository(inner OrderRepositoryInterface, b *breaker.Breaker) OrderRepositoryInterface {
+	return &BreakerOrderRepository{inner: inner, breaker: b}
+}
+
+// IsInfrastructureError отделяет отказ БД (сеть, таймаут, перегрузка, рестарт)
+// от ожидаемых ошибок бизнес-логики и ошибок в самих данных. Отказом считаются
+// только ошибки соединения, истёкший дедлайн и перечисленные классы SQLSTATE;
+// всё остальное (например, pii.ErrNoKey или битый шифротекст) — нет.
+func IsInfrastructureError(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, breaker.ErrOpen):
+		return false
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, io.ErrUnexpectedEOF), // соединение оборвалось посреди ответа
+		pgconn.Timeout(err):
+		return true
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && len(pgErr.Code) >= 2 {
+		switch pgErr.Code[:2] {
+		case "08", // connection exception
+			"40", // serialization failure, deadlock
+			"53", // insufficient resources
+			"57", // operator intervention: shutdown, query canceled
+			"58": // system error
+			return true
+		}
+	}
+	return false
+}
+
+func (r *BreakerOrderRepository) Create(order *models.Order) error {
+	return r.breaker.Do(func() error {
+		return r.inner.Create(order)
+	})
+}
+
+func (r *BreakerOrderRepository) FindByOrderUID(orderUID string) (*models.Order, error) {
+	var order *models.Order
+	err := r.breaker.Do(func() error {
+		var err error
+		order, err = r.inner.FindByOrderUID(orderUID)
+		return err
+	})
+	return order, err
+}
+
+func (r *BreakerOrderRepository) FindByOrderUIDWith(orderUID string, include Include) (*models.Order, error) {
+	var order *models.Order
+	err := r.breaker.Do(func() error {
+		var err error
+		order, err = r.inner.FindByOrderUIDWith(orderUID, include)
+		return err
+	})
+	return order, err
+}
+
+func (r *BreakerOrderRepository) FindByOrderUIDs(orderUIDs []string) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.breaker.Do(func() error {
+		var err error
+		orders, err = r.inner.FindByOrderUIDs(orderUIDs)
+		return err
+	})
+	return orders, err
+}
+
+func (r *BreakerOrderRepository) FindByTrackNumber(trackNumber string, include Include) (*models.Order, error) {
+	var order *models.Order
+	err := r.breaker.Do(func() error {
+		var err error
+		order, err = r.inner.FindByTrackNumber(trackNumber, include)
+		return err
+	})
+	return order, err
+}
+
+func (r *BreakerOrderRepository) FindByTransaction(transaction string, include Include) (*models.Order, error) {
+	var order *models.Order
+	err := r.breaker.Do(func() error {
+		var err error
+		order, err = r.inner.FindByTransaction(transaction, include)
+		return err
+	})
+	return order, err
+}
+
+func (r *BreakerOrderRepository) FindOrderUIDsByCustomer(customerID string) ([]string, error) {
+	var uids []string
+	err := r.breaker.Do(func() error {
+		var err error
+		uids, err = r.inner.FindOrderUIDsByCustomer(customerID)
+		return err
+	})
+	return uids, err
+}
+
+func (r *BreakerOrderRepository) GetAllOrderUIDs() ([]string, error) {
+	var uids []string
+	err := r.breaker.Do(func() error {
+		var err error
+		uids, err = r.inner.GetAllOrderUIDs()
+		return err
+	})
+	return uids, err
+}
+
+func (r *BreakerOrderRepository) ChangeStatus(orderUID string, from, to models.OrderStatus, reason string, at time.Time) (*models.Order, error) {
+	var order *models.Order
+	err := r.breaker.Do(func() error {
+		var err error
+		order, err = r.inner.ChangeStatus(orderUID, from, to, reason, at)
+		return err
+	})
+	return order, err
+}
+
+func (r *BreakerOrderRepository) GetStatusHistory(orderUID string) ([]models.StatusHistory, error) {
+	var history []models.StatusHistory
+	err := r.breaker.Do(func() error {
+		var err error
+		history, err = r.inner.GetStatusHistory(orderUID)
+		return err
+	})
+	return history, err
+}
+
+func (r *BreakerOrderRepository) SoftDelete(orderUID string, at time.Time) error {
+	return r.breaker.Do(func() error {
+		return r.inner.SoftDelete(orderUID, at)
+	})
+}
+
+func (r *BreakerOrderRepository) Purge(orderUID string) error {
+	return r.breaker.Do(func() error {
+		return r.inner.Purge(orderUID)
+	})
+}
+
+func (r *BreakerOrderRepository) Search(search OrderSearch) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.breaker.Do(func() error {
+		var err error
+		orders, err = r.inner.Search(search)
+		return err
+	})
+	return orders, err
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RouterOptions — настройки маршрутизации чтений на реплики
+type RouterOptions struct {
+	MaxLag        time.Duration // реплика с большим отставанием исключается из ротации
+	StickyWindow  time.Duration // сколько после записи читать заказ только с primary
+	CheckInterval time.Duration // как часто проверять доступность и отставание реплик
+}
+
+type replica struct {
+	db      *gorm.DB
+	index   int
+	healthy atomic.Bool
+}
+
+// DBRouter отправляет записи на primary, а чтения — по кругу на живые реплики.
+// Если живых реплик нет, чтение идёт на primary.
+type DBRouter struct {
+	primary  *gorm.DB
+	replicas []*replica
+	opts     RouterOptions
+	next     atomic.Uint64
+
+	mu     sync.Mutex
+	writes map[string]time.Time
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewDBRouter(primary *gorm.DB, replicas []*gorm.DB, opts RouterOptions) *DBRouter {
+	r := &DBRouter{
+		primary: primary,
+		opts:    opts,
+		writes:  make(map[string]time.Time),
+		stop:    make(chan struct{}),
+	}
+	for i, db := range replicas {
+		rep := &replica{db: db, index: i}
+		rep.healthy.Store(true)
+		r.replicas = append(r.replicas, rep)
+	}
+	return r
+}
+
+func (r *DBRouter) Primary() *gorm.DB {
+	return r.primary
+}
+
+// Reader возвращает соединение для чтения. Если заказ с ключом key недавно
+// записывался, возвращается primary, чтобы не прочитать устаревшие данные.
+func (r *DBRouter) Reader(key string) *gorm.DB {
+	if len(r.replicas) == 0 || r.recentlyWritten(key) {
+		return r.primary
+	}
+
+	n := uint64(len(r.replicas))
+	start := r.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if rep := r.replicas[(start+i)%n]; rep.healthy.Load() {
+			return rep.db
+		}
+	}
+	return r.primary
+}
+
+// MarkWritten запоминает запись по ключам для sticky-чтения с primary
+func (r *DBRouter) MarkWritten(keys ...string) {
+	if len(r.replicas) == 0 || r.opts.StickyWindow <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	until := time.Now().Add(r.opts.StickyWindow)
+	for _, key := range keys {
+		if key != "" {
+			r.writes[key] = until
+		}
+	}
+}
+
+func (r *DBRouter) recentlyWritten(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	until, ok := r.writes[key]
+	if ok && time.Now().After(until) {
+		delete(r.writes, key)
+		return false
+	}
+	return ok
+}
+
+// Start запускает периодическую проверку реплик
+func (r *DBRouter) Start() {
+	if len(r.replicas) == 0 {
+		return
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.opts.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			r.checkReplicas()
+
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (r *DBRouter) checkReplicas() {
+	for _, rep := range r.replicas {
+		// Время последней применённой транзакции растёт и на простаивающем primary,
+		// поэтому реплика, применившая весь полученный WAL, считается догнавшей
+		var lagSeconds float64
+		err := rep.db.Raw(`
+			SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+				ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END`,
+		).Scan(&lagSeconds).Error
+
+		lag := time.Duration(lagSeconds * float64(time.Second))
+		healthy := err == nil && (r.opts.MaxLag <= 0 || lag <= r.opts.MaxLag)
+		if was := rep.healthy.Swap(healthy); was != healthy {
+			if healthy {
+				log.Printf("✅ Replica #%d is back in rotation", rep.index)
+			} else {
+				log.Printf("⚠️  Replica #%d removed from rotation: err=%v lag=%s", rep.index, err, lag)
+			}
+		}
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	for key, until := range r.writes {
+		if now.After(until) {
+			delete(r.writes, key)
+		}
+	}
+	r.mu.Unlock()
+}
+
+func (r *DBRouter) Close() error {
+	close(r.stop)
+	r.wg.Wait()
+	return nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"gorm.io/gorm"
+)
+
+// Ключи sticky-чтения для поиска не по order_uid: после записи заказа
+// поиск по его треку, транзакции и клиенту тоже идёт на primary
+func trackKey(track string) string         { return "track:" + track }
+func transactionKey(tx string) string      { return "transaction:" + tx }
+func customerKey(customerID string) string { return "customer:" + customerID }
+
+// stickyKeys — все ключи, по которым может читаться заказ
+func stickyKeys(order *models.Order) []string {
+	keys := []string{order.OrderUID, trackKey(order.TrackNumber), customerKey(order.CustomerID)}
+	if order.Payment != nil {
+		keys = append(keys, transactionKey(order.Payment.Transaction))
+	}
+	return keys
+}
+
+// FindByOrderUIDs загружает заказы одним запросом с preload; ненайденных нет в результате
+func (r *OrderRepository) FindByOrderUIDs(orderUIDs []string) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.read("", func(db *gorm.DB) error {
+		orders = nil
+		return db.
+			Preload("Delivery").
+			Preload("Payment").
+			Preload("Items").
+			Where("order_uid IN ?", orderUIDs).
+			Find(&orders).Error
+	})
+	return orders, err
+}
+
+// FindByTrackNumber находит заказ по трек-номеру через глобальный индекс order_keys
+// и загружает его с ассоциациями из include
+func (r *OrderRepository) FindByTrackNumber(trackNumber string, include Include) (*models.Order, error) {
+	var order *models.Order
+	err := r.read(trackKey(trackNumber), func(db *gorm.DB) error {
+		var key models.OrderKey
+		if err := db.Select("order_uid").Where("track_number = ?", trackNumber).First(&key).Error; err != nil {
+			return err
+		}
+		var err error
+		order, err = findOrder(db, key.OrderUID, include)
+		return err
+	})
+	return order, err
+}
+
+// FindByTransaction находит заказ по идентификатору платёжной транзакции
+// и загружает его с ассоциациями из include
+func (r *OrderRepository) FindByTransaction(transaction string, include Include) (*models.Order, error) {
+	var order *models.Order
+	err := r.read(transactionKey(transaction), func(db *gorm.DB) error {
+		var payment models.Payment
+		if err := db.Select("order_id").Where("transaction = ?", transaction).First(&payment).Error; err != nil {
+			return err
+		}
+		var err error
+		order, err = findOrder(db, payment.OrderID, include)
+		return err
+	})
+	return order, err
+}
+
+// FindOrderUIDsByCustomer возвращает все заказы клиента, новые первыми
+func (r *OrderRepository) FindOrderUIDsByCustomer(customerID string) ([]string, error) {
+	var uids []string
+	err := r.read(customerKey(customerID), func(db *gorm.DB) error {
+		uids = nil
+		return db.Model(&models.Order{}).
+			Where("customer_id = ?", customerID).
+			Order("date_created DESC, order_uid").
+			Pluck("order_uid", &uids).Error
+	})
+	return uids, err
+}
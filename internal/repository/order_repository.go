@@ -1,27 +1,74 @@
 package repository
 
 import (
+	"errors"
+	"time"
+
 	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// ErrDuplicate — заказ с таким order_uid или track_number уже существует
+var ErrDuplicate = errors.New("order already exists")
+
+// ErrStatusConflict — статус заказа изменился параллельно
+var ErrStatusConflict = errors.New("order status was changed concurrently")
+
 type OrderRepositoryInterface interface {
 	Create(order *models.Order) error
 	FindByOrderUID(orderUID string) (*models.Order, error)
+	FindByOrderUIDWith(orderUID string, include Include) (*models.Order, error)
+	FindByOrderUIDs(orderUIDs []string) ([]models.Order, error)
+	FindByTrackNumber(trackNumber string, include Include) (*models.Order, error)
+	FindByTransaction(transaction string, include Include) (*models.Order, error)
+	FindOrderUIDsByCustomer(customerID string) ([]string, error)
 	GetAllOrderUIDs() ([]string, error)
+	ChangeStatus(orderUID string, from, to models.OrderStatus, reason string, at time.Time) (*models.Order, error)
+	GetStatusHistory(orderUID string) ([]models.StatusHistory, error)
+	SoftDelete(orderUID string, at time.Time) error
+	Purge(orderUID string) error
+	Search(search OrderSearch) ([]models.Order, error)
 }
 
 type OrderRepository struct {
-	db *gorm.DB
+	db        *gorm.DB
+	router    *DBRouter
+	reporting string // валюта отчётности дневных итогов (applyRollup)
+}
+
+func NewOrderRepository(db *gorm.DB, reportingCurrency string) OrderRepositoryInterface {
+	return NewReplicatedOrderRepository(NewDBRouter(db, nil, RouterOptions{}), reportingCurrency)
+}
+
+// NewReplicatedOrderRepository пишет в primary, а чтения распределяет по репликам
+func NewReplicatedOrderRepository(router *DBRouter, reportingCurrency string) OrderRepositoryInterface {
+	return &OrderRepository{db: router.Primary(), router: router, reporting: reportingCurrency}
 }
 
-func NewOrderRepository(db *gorm.DB) OrderRepositoryInterface {
-	return &OrderRepository{db: db}
+// read выполняет чтение на реплике. При ошибке, в т.ч. если реплика ещё
+// не получила заказ, чтение повторяется на primary.
+func (r *OrderRepository) read(key string, fn func(db *gorm.DB) error) error {
+	db := r.router.Reader(key)
+	err := fn(db)
+	if err != nil && db != r.db {
+		return fn(r.db)
+	}
+	return err
 }
 
 func (r *OrderRepository) Create(order *models.Order) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// order_keys держит глобальную уникальность order_uid/track_number
+		if err := tx.Create(&models.OrderKey{
+			OrderUID:    order.OrderUID,
+			TrackNumber: order.TrackNumber,
+			DateCreated: order.DateCreated,
+		}).Error; err != nil {
+			return err
+		}
+
 		if err := tx.Create(order).Error; err != nil {
 			return err
 		}
@@ -51,17 +98,76 @@ func (r *OrderRepository) Create(order *models.Order) error {
 			}
 		}
 
-		return nil
+		if err := tx.Create(&models.StatusHistory{
+			OrderUID:  order.OrderUID,
+			ToStatus:  order.Status,
+			ChangedAt: time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := applyRollup(tx, order, 1, r.reporting); err != nil {
+			return err
+		}
+
+		return enqueueEvent(tx, models.EventOrderCreated, order.OrderUID, order)
 	})
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	if err == nil {
+		r.router.MarkWritten(stickyKeys(order)...)
+	}
+	return err
+}
+
+// Include — какие ассоциации заказа загружать вместе с ним
+type Include struct {
+	Delivery bool
+	Payment  bool
+	Items    bool
 }
 
+// IncludeAll — заказ целиком
+var IncludeAll = Include{Delivery: true, Payment: true, Items: true}
+
 func (r *OrderRepository) FindByOrderUID(orderUID string) (*models.Order, error) {
+	return r.FindByOrderUIDWith(orderUID, IncludeAll)
+}
+
+// FindByOrderUIDWith загружает заказ только с ассоциациями из include
+func (r *OrderRepository) FindByOrderUIDWith(orderUID string, include Include) (*models.Order, error) {
+	var order *models.Order
+	err := r.read(orderUID, func(db *gorm.DB) error {
+		var err error
+		order, err = findOrder(db, orderUID, include)
+		return err
+	})
+	return order, err
+}
+
+// findOrder загружает заказ с ассоциациями из include. Дата создания берётся из order_keys,
+// чтобы запросы к секционированным orders и items затрагивали одну секцию.
+func findOrder(db *gorm.DB, orderUID string, include Include) (*models.Order, error) {
+	var key models.OrderKey
+	if err := db.Select("date_created").Where("order_uid = ?", orderUID).First(&key).Error; err != nil {
+		return nil, err
+	}
+
+	query := db
+	if include.Delivery {
+		query = query.Preload("Delivery")
+	}
+	if include.Payment {
+		query = query.Preload("Payment")
+	}
+	if include.Items {
+		query = query.Preload("Items", "date_created = ?", key.DateCreated)
+	}
+
 	var order models.Order
-	if err := r.db.
-		Preload("Delivery").
-		Preload("Payment").
-		Preload("Items").
-		Where("order_uid = ?", orderUID).
+	if err := query.
+		Where("order_uid = ? AND date_created = ?", orderUID, key.DateCreated).
 		First(&order).Error; err != nil {
 		return nil, err
 	}
@@ -70,6 +176,141 @@ func (r *OrderRepository) FindByOrderUID(orderUID string) (*models.Order, error)
 
 func (r *OrderRepository) GetAllOrderUIDs() ([]string, error) {
 	var uids []string
-	err := r.db.Model(&models.Order{}).Pluck("order_uid", &uids).Error
+	err := r.read("", func(db *gorm.DB) error {
+		uids = nil
+		return db.Model(&models.Order{}).Pluck("order_uid", &uids).Error
+	})
 	return uids, err
 }
+
+func (r *OrderRepository) ChangeStatus(orderUID string, from, to models.OrderStatus, reason string, at time.Time) (*models.Order, error) {
+	var order models.Order
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Условие на текущий статус защищает от гонки двух параллельных переходов
+		res := tx.Model(&models.Order{}).
+			Where("order_uid = ? AND status = ?", orderUID, from).
+			Updates(map[string]any{"status": to, "updated_at": at})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrStatusConflict
+		}
+
+		if err := tx.Create(&models.StatusHistory{
+			OrderUID:   orderUID,
+			FromStatus: from,
+			ToStatus:   to,
+			Reason:     reason,
+			ChangedAt:  at,
+		}).Error; err != nil {
+			return err
+		}
+
+		updated, err := findOrder(tx, orderUID, IncludeAll)
+		if err != nil {
+			return err
+		}
+		order = *updated
+
+		return enqueueEvent(tx, models.EventOrderUpdated, orderUID, &order)
+	})
+	// После конфликта статус мог прийти с отстающей реплики: повторное чтение — с primary
+	if err != nil {
+		r.router.MarkWritten(orderUID)
+		return nil, err
+	}
+	r.router.MarkWritten(stickyKeys(&order)...)
+	return &order, nil
+}
+
+func (r *OrderRepository) GetStatusHistory(orderUID string) ([]models.StatusHistory, error) {
+	var history []models.StatusHistory
+	err := r.read(orderUID, func(db *gorm.DB) error {
+		return db.
+			Where("order_uid = ?", orderUID).
+			Order("changed_at, id").
+			Find(&history).Error
+	})
+	return history, err
+}
+
+// SoftDelete помечает заказ удалённым (deleted_at), статус не меняется.
+// Удалённые заказы не попадают в выборки.
+func (r *OrderRepository) SoftDelete(orderUID string, at time.Time) error {
+	keys := []string{orderUID}
+	defer func() { r.router.MarkWritten(keys...) }()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		order, err := findOrder(tx, orderUID, IncludeAll)
+		if err != nil {
+			return err
+		}
+		keys = stickyKeys(order)
+
+		if err := tx.Model(&models.Order{}).
+			Where("order_uid = ? AND date_created = ?", orderUID, order.DateCreated).
+			Updates(map[string]any{"updated_at": at, "deleted_at": at}).Error; err != nil {
+			return err
+		}
+		if err := applyRollup(tx, order, -1, r.reporting); err != nil {
+			return err
+		}
+
+		return enqueueEvent(tx, models.EventOrderDeleted, orderUID, order)
+	})
+}
+
+// Purge безвозвратно удаляет заказ (в т.ч. мягко удалённый) со всеми связанными строками
+func (r *OrderRepository) Purge(orderUID string) error {
+	keys := []string{orderUID}
+	defer func() { r.router.MarkWritten(keys...) }()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Мягко удалённый заказ уже вычтен из итогов в SoftDelete
+		var live models.Order
+		err := tx.Preload("Payment").Where("order_uid = ?", orderUID).First(&live).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			keys = stickyKeys(&live)
+			if err := applyRollup(tx, &live, -1, r.reporting); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("order_id = ?", orderUID).Delete(&models.Item{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_id = ?", orderUID).Delete(&models.Payment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_id = ?", orderUID).Delete(&models.Delivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("order_uid = ?", orderUID).Delete(&models.StatusHistory{}).Error; err != nil {
+			return err
+		}
+
+		res := tx.Unscoped().Where("order_uid = ?", orderUID).Delete(&models.Order{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("order_uid = ?", orderUID).Delete(&models.OrderKey{}).Error; err != nil {
+			return err
+		}
+
+		return enqueueEvent(tx, models.EventOrderPurged, orderUID, nil)
+	})
+}
+
+// isUniqueViolation распознаёт нарушение уникального ограничения Postgres (23505)
+func isUniqueViolation(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepositoryInterface interface {
+	// ProcessPending блокирует до limit неотправленных событий и передаёт их в publish.
+	// При успехе publish события помечаются отправленными в той же транзакции.
+	ProcessPending(limit int, publish func([]models.OutboxEvent) error) (int, error)
+	// DeleteSentBefore удаляет отправленные события старше before
+	DeleteSentBefore(before time.Time) (int64, error)
+}
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepositoryInterface {
+	return &OutboxRepository{db: db}
+}
+
+func (r *OutboxRepository) ProcessPending(limit int, publish func([]models.OutboxEvent) error) (int, error) {
+	var events []models.OutboxEvent
+	var publishErr error
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// SKIP LOCKED позволяет нескольким репликам сервиса разбирать outbox параллельно
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("sent_at IS NULL").
+			Order("id").
+			Limit(limit).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+		}
+
+		if publishErr = publish(events); publishErr != nil {
+			// Фиксируем попытку, событие останется в очереди
+			return tx.Model(&models.OutboxEvent{}).
+				Where("id IN ?", ids).
+				Updates(map[string]any{
+					"attempts":   gorm.Expr("attempts + 1"),
+					"last_error": publishErr.Error(),
+				}).Error
+		}
+
+		return tx.Model(&models.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{
+				"attempts": gorm.Expr("attempts + 1"),
+				"sent_at":  time.Now(),
+			}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	if publishErr != nil {
+		return 0, publishErr
+	}
+	return len(events), nil
+}
+
+func (r *OutboxRepository) DeleteSentBefore(before time.Time) (int64, error) {
+	res := r.db.
+		Where("sent_at IS NOT NULL AND sent_at < ?", before).
+		Delete(&models.OutboxEvent{})
+	return res.RowsAffected, res.Error
+}
+
+// enqueueEvent записывает событие заказа в outbox внутри транзакции tx.
+// order может быть nil, если заказа уже нет (order.purged). Персональные данные
+// в событии зашифрованы так же, как в столбцах БД.
+func enqueueEvent(tx *gorm.DB, eventType, orderUID string, order *models.Order) error {
+	order, err := order.SealPII()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(models.OrderEvent{
+		EventType:  eventType,
+		OrderUID:   orderUID,
+		OccurredAt: time.Now().UTC(),
+		Order:      order,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.OutboxEvent{
+		EventType:   eventType,
+		AggregateID: orderUID,
+		Payload:     payload,
+	}).Error
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// partitionedTables — таблицы, секционированные по date_created (см. миграцию 000005)
+var partitionedTables = map[string]bool{"orders": true, "items": true}
+
+const partitionBoundLayout = "2006-01-02 15:04:05Z07:00"
+
+type PartitionRepositoryInterface interface {
+	// IsPartitioned сообщает, секционирована ли таблица
+	IsPartitioned(table string) (bool, error)
+	// DefaultMonths возвращает месяцы, строки которых лежат в DEFAULT-секции
+	DefaultMonths(table string) ([]time.Time, error)
+	// EnsureMonth создаёт секцию table за месяц month, если её ещё нет,
+	// и переносит в неё подходящие строки из DEFAULT-секции
+	EnsureMonth(table string, month time.Time) (bool, error)
+}
+
+type PartitionRepository struct {
+	db *gorm.DB
+}
+
+func NewPartitionRepository(db *gorm.DB) PartitionRepositoryInterface {
+	return &PartitionRepository{db: db}
+}
+
+func (r *PartitionRepository) IsPartitioned(table string) (bool, error) {
+	if !partitionedTables[table] {
+		return false, fmt.Errorf("table %q is not partitioned by date_created", table)
+	}
+
+	var count int64
+	err := r.db.Raw(
+		"SELECT count(*) FROM pg_partitioned_table p JOIN pg_class c ON c.oid = p.partrelid WHERE c.relname = ?",
+		table,
+	).Scan(&count).Error
+	return count > 0, err
+}
+
+func (r *PartitionRepository) DefaultMonths(table string) ([]time.Time, error) {
+	if !partitionedTables[table] {
+		return nil, fmt.Errorf("table %q is not partitioned by date_created", table)
+	}
+
+	var months []time.Time
+	err := r.db.Raw(fmt.Sprintf(
+		"SELECT DISTINCT date_trunc('month', date_created AT TIME ZONE 'UTC') FROM %s_default ORDER BY 1", table,
+	)).Scan(&months).Error
+	return months, err
+}
+
+func (r *PartitionRepository) EnsureMonth(table string, month time.Time) (bool, error) {
+	if !partitionedTables[table] {
+		return false, fmt.Errorf("table %q is not partitioned by date_created", table)
+	}
+
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	name := fmt.Sprintf("%s_p%s", table, from.Format("2006_01"))
+	// Границы секций — начала месяцев в UTC, date_created хранится как TIMESTAMPTZ
+	lower, upper := from.Format(partitionBoundLayout), to.Format(partitionBoundLayout)
+	bounds := fmt.Sprintf("FROM ('%s') TO ('%s')", lower, upper)
+
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Несколько реплик сервиса могут обслуживать секции одновременно
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", name).Error; err != nil {
+			return err
+		}
+
+		var exists bool
+		if err := tx.Raw("SELECT to_regclass(?) IS NOT NULL", name).Scan(&exists).Error; err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+
+		// Строки этого месяца из DEFAULT-секции переносим до подключения новой секции,
+		// иначе ATTACH PARTITION упадёт на проверке DEFAULT
+		statements := []string{
+			fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS)", name, table),
+			fmt.Sprintf(
+				"WITH moved AS (DELETE FROM %s_default WHERE date_created >= '%s' AND date_created < '%s' RETURNING *) INSERT INTO %s SELECT * FROM moved",
+				table, lower, upper, name,
+			),
+			fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s FOR VALUES %s", table, name, bounds),
+		}
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+
+		created = true
+		return nil
+	})
+	return created, err
+}
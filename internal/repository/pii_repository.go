@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+	"gorm.io/gorm"
+)
+
+// piiTable — таблица с персональными данными и ключом для постраничного обхода
+type piiTable struct {
+	name    string
+	key     string
+	keyType string // тип ключа для сравнения в keyset-обходе; пусто — uuid
+	columns []string
+	// rotate приводит значение столбца к активному ключу; nil — столбец serializer:pii
+	rotate func(ring *pii.Keyring, value string) (string, bool, error)
+}
+
+var piiTables = []piiTable{
+	{name: "delivery", key: "order_id", columns: []string{"name", "phone", "address", "email"}},
+	{name: "orders", key: "order_uid", columns: []string{"internal_signature"}},
+	// JSON-снимки заказа: события outbox и архив в таблице
+	{name: "outbox", key: "id", keyType: "bigint", columns: []string{"payload"}, rotate: rotateSnapshot},
+	{name: "orders_archive", key: "order_uid", columns: []string{"payload"}, rotate: rotateSnapshot},
+}
+
+// rotateSnapshot приводит к активному ключу персональные данные в JSON-снимке заказа
+func rotateSnapshot(ring *pii.Keyring, doc string) (string, bool, error) {
+	changed := false
+	rotated, err := models.MapSnapshotPII([]byte(doc), func(value string) (string, error) {
+		rotated, ok, err := ring.Rotate(value)
+		changed = changed || ok
+		return rotated, err
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return string(rotated), changed, nil
+}
+
+type PIIRepositoryInterface interface {
+	// Reencrypt приводит столбцы PII к активному ключу ring: шифрует открытые
+	// значения и перешифровывает значения под другими ключами. Возвращает
+	// число изменённых строк.
+	Reencrypt(ring *pii.Keyring, batchSize int) (int64, error)
+}
+
+type PIIRepository struct {
+	db *gorm.DB
+}
+
+func NewPIIRepository(db *gorm.DB) PIIRepositoryInterface {
+	return &PIIRepository{db: db}
+}
+
+func (r *PIIRepository) Reencrypt(ring *pii.Keyring, batchSize int) (int64, error) {
+	var total int64
+	for _, table := range piiTables {
+		n, err := r.reencryptTable(ring, table, batchSize)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", table.name, err)
+		}
+	}
+	return total, nil
+}
+
+// reencryptTable обходит таблицу пачками по ключу; каждая пачка — отдельная
+// транзакция, поэтому прерванный обход можно просто запустить заново
+func (r *PIIRepository) reencryptTable(ring *pii.Keyring, table piiTable, batchSize int) (int64, error) {
+	var total int64
+	after := ""
+	for {
+		rows, err := r.selectBatch(table, after, batchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(rows) == 0 {
+			return total, nil
+		}
+
+		err = r.db.Transaction(func(tx *gorm.DB) error {
+			for _, row := range rows {
+				updated, err := r.reencryptRow(tx, ring, table, row)
+				if err != nil {
+					return fmt.Errorf("%s %s: %w", table.key, row.key, err)
+				}
+				total += updated
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		after = rows[len(rows)-1].key
+	}
+}
+
+type piiRow struct {
+	key    string
+	values []sql.NullString
+}
+
+func (r *PIIRepository) selectBatch(table piiTable, after string, limit int) ([]piiRow, error) {
+	columns := make([]string, len(table.columns))
+	for i, column := range table.columns {
+		columns[i] = column + "::text"
+	}
+	query := fmt.Sprintf("SELECT %s::text, %s FROM %s", table.key, strings.Join(columns, ", "), table.name)
+	args := []any{}
+	if after != "" {
+		query += fmt.Sprintf(" WHERE %s > ?::%s", table.key, table.keyTypeOrUUID())
+		args = append(args, after)
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT ?", table.key)
+	args = append(args, limit)
+
+	rows, err := r.db.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []piiRow
+	for rows.Next() {
+		row := piiRow{values: make([]sql.NullString, len(table.columns))}
+		dest := []any{&row.key}
+		for i := range row.values {
+			dest = append(dest, &row.values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+	return batch, rows.Err()
+}
+
+// reencryptRow обновляет строку, только если её значения не изменились с момента
+// чтения: параллельная запись сервиса уже зашифрована активным ключом
+func (r *PIIRepository) reencryptRow(tx *gorm.DB, ring *pii.Keyring, table piiTable, row piiRow) (int64, error) {
+	var sets, conds []string
+	var setArgs, condArgs []any
+	for i, column := range table.columns {
+		old := row.values[i]
+		if !old.Valid {
+			continue
+		}
+		rotate := table.rotate
+		if rotate == nil {
+			rotate = func(ring *pii.Keyring, value string) (string, bool, error) { return ring.Rotate(value) }
+		}
+		rotated, changed, err := rotate(ring, old.String)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", column, err)
+		}
+		if !changed {
+			continue
+		}
+		sets = append(sets, column+" = ?")
+		setArgs = append(setArgs, rotated)
+		conds = append(conds, column+"::text = ?")
+		condArgs = append(condArgs, old.String)
+	}
+	if len(sets) == 0 {
+		return 0, nil
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?::%s AND %s",
+		table.name, strings.Join(sets, ", "), table.key, table.keyTypeOrUUID(), strings.Join(conds, " AND "))
+	args := append(setArgs, row.key)
+	args = append(args, condArgs...)
+	res := tx.Exec(query, args...)
+	return res.RowsAffected, res.Error
+}
+
+func (t piiTable) keyTypeOrUUID() string {
+	if t.keyType == "" {
+		return "uuid"
+	}
+	return t.keyType
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"gorm.io/gorm"
+)
+
+// applyRollup добавляет заказ в дневные итоги (sign = 1) или вычитает его (sign = -1).
+// Вызывается в транзакции, которая создаёт или удаляет заказ. reporting — валюта
+// отчётности: сумма в другой валюте считается непересчитанной, как в Rebuild.
+func applyRollup(tx *gorm.DB, order *models.Order, sign int64, reporting string) error {
+	if order.Payment == nil {
+		return nil
+	}
+	// Сумма в валюте отчётности есть, только если на дату заказа нашёлся курс
+	var normalized, unconverted int64
+	if n := order.Payment.Normalized; n != nil && n.Amount.Currency == reporting {
+		normalized = sign * n.Amount.Amount
+	} else {
+		unconverted = sign
+	}
+	// Дни итогов — календарные дни UTC
+	return tx.Exec(`
+		INSERT INTO order_rollups_daily (day, delivery_service, provider, currency, orders, revenue, revenue_normalized, unconverted)
+		VALUES (?::date, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (day, delivery_service, provider, currency) DO UPDATE SET
+			orders = order_rollups_daily.orders + EXCLUDED.orders,
+			revenue = order_rollups_daily.revenue + EXCLUDED.revenue,
+			revenue_normalized = order_rollups_daily.revenue_normalized + EXCLUDED.revenue_normalized,
+			unconverted = order_rollups_daily.unconverted + EXCLUDED.unconverted`,
+		order.DateCreated.UTC().Format(time.DateOnly), order.DeliveryService, order.Payment.Provider, order.Payment.Currency,
+		sign, sign*int64(order.Payment.Amount), normalized, unconverted,
+	).Error
+}
+
+// ErrArchivedRange — в диапазоне пересчёта есть архивные заказы: горячих таблиц
+// для него недостаточно, а пересчёт стёр бы их вклад в итоги
+var ErrArchivedRange = errors.New("rollup range contains archived orders")
+
+type RollupRepositoryInterface interface {
+	// Rebuild пересчитывает итоги за дни [from, to) по сырым данным и возвращает число строк.
+	// Дни с архивными заказами не пересчитываются: возвращается ErrArchivedRange.
+	Rebuild(from, to time.Time) (int64, error)
+}
+
+type RollupRepository struct {
+	db        *gorm.DB
+	reporting string // валюта отчётности: revenue_normalized считается только в ней
+}
+
+func NewRollupRepository(db *gorm.DB, reportingCurrency string) RollupRepositoryInterface {
+	return &RollupRepository{db: db, reporting: reportingCurrency}
+}
+
+func (r *RollupRepository) Rebuild(from, to time.Time) (int64, error) {
+	var rows int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Блокирует инкременты из параллельных транзакций записи до конца пересчёта
+		if err := tx.Exec("LOCK TABLE order_rollups_daily IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+		// Архив бывает в файлах, пересчитать его вклад запросом нельзя
+		var lastArchived sql.NullTime
+		if err := tx.Raw("SELECT MAX(date_created) FROM orders_archive WHERE date_created < ?", utcDay(to)).
+			Scan(&lastArchived).Error; err != nil {
+			return err
+		}
+		if lastArchived.Valid && !lastArchived.Time.Before(utcDay(from)) {
+			return fmt.Errorf("%w: rebuild from %s or later", ErrArchivedRange,
+				utcDay(lastArchived.Time.UTC()).AddDate(0, 0, 1).Format(time.DateOnly))
+		}
+		if err := tx.Exec("DELETE FROM order_rollups_daily WHERE day >= ?::date AND day < ?::date",
+			from.Format(time.DateOnly), to.Format(time.DateOnly)).Error; err != nil {
+			return err
+		}
+
+		res := tx.Exec(`
+			INSERT INTO order_rollups_daily (day, delivery_service, provider, currency, orders, revenue, revenue_normalized, unconverted)
+			SELECT (o.date_created AT TIME ZONE 'UTC')::date, o.delivery_service, p.provider, p.currency, COUNT(*), SUM(p.amount),
+				COALESCE(SUM(p.amount_normalized) FILTER (WHERE p.normalized_currency = @reporting), 0),
+				COUNT(*) FILTER (WHERE p.normalized_currency <> @reporting)
+			FROM orders o
+			JOIN payment p ON p.order_id = o.order_uid
+			WHERE o.deleted_at IS NULL AND o.date_created >= @from AND o.date_created < @to
+			GROUP BY 1, 2, 3, 4`,
+			sql.Named("reporting", r.reporting), sql.Named("from", utcDay(from)), sql.Named("to", utcDay(to)))
+		rows = res.RowsAffected
+		return res.Error
+	})
+	return rows, err
+}
+
+// utcDay — начало дня t в UTC
+func utcDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"gorm.io/gorm"
+)
+
+// Выражения совпадают с индексами из миграции 000006_add_search_indexes.
+// При шифровании PII (PII_ENCRYPTION_KEY) name, phone и email в delivery
+// хранятся шифротекстом и по ним ничего не находится.
+const (
+	deliverySearchVector = `to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(city, '') || ' ' ||
+                          coalesce(phone, '') || ' ' || coalesce(email, ''))`
+	itemsSearchVector = `to_tsvector('simple', name || ' ' || brand)`
+)
+
+// OrderSearch — критерии поиска заказов; заданные критерии объединяются через AND
+type OrderSearch struct {
+	Text        string // полнотекстовый поиск по имени, городу, телефону, email, товарам и брендам
+	TrackNumber string
+	Transaction string
+	RID         string
+	NMID        int64
+	ChrtID      int64
+
+	Limit  int
+	Offset int
+}
+
+// Empty — не задано ни одного критерия
+func (s OrderSearch) Empty() bool {
+	return TextQuery(s.Text) == "" && s.TrackNumber == "" && s.Transaction == "" &&
+		s.RID == "" && s.NMID == 0 && s.ChrtID == 0
+}
+
+func (r *OrderRepository) Search(search OrderSearch) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.read("", func(db *gorm.DB) error {
+		orders = nil
+		return applySearch(db, search).
+			Preload("Delivery").
+			Preload("Payment").
+			Preload("Items").
+			Order("date_created DESC, order_uid").
+			Limit(search.Limit).
+			Offset(search.Offset).
+			Find(&orders).Error
+	})
+	return orders, err
+}
+
+func applySearch(db *gorm.DB, s OrderSearch) *gorm.DB {
+	if query := TextQuery(s.Text); query != "" {
+		db = db.Where(
+			"order_uid IN (SELECT order_id FROM delivery WHERE "+deliverySearchVector+" @@ to_tsquery('simple', ?)"+
+				" UNION SELECT order_id FROM items WHERE "+itemsSearchVector+" @@ to_tsquery('simple', ?))",
+			query, query,
+		)
+	}
+	if s.TrackNumber != "" {
+		db = db.Where("order_uid IN (SELECT order_uid FROM order_keys WHERE track_number = ?)", s.TrackNumber)
+	}
+	if s.Transaction != "" {
+		db = db.Where("order_uid IN (SELECT order_id FROM payment WHERE transaction = ?)", s.Transaction)
+	}
+	if s.RID != "" {
+		db = db.Where("order_uid IN (SELECT order_id FROM items WHERE rid = ?)", s.RID)
+	}
+	if s.NMID != 0 {
+		db = db.Where("order_uid IN (SELECT order_id FROM items WHERE nm_id = ?)", s.NMID)
+	}
+	if s.ChrtID != 0 {
+		db = db.Where("order_uid IN (SELECT order_id FROM items WHERE chrt_id = ?)", s.ChrtID)
+	}
+	return db
+}
+
+// TextQuery превращает пользовательскую строку в tsquery: каждое слово ищется
+// по префиксу, слова объединяются через AND. Слова берутся в кавычки, поэтому
+// операторы tsquery во вводе (&, |, !, скобки) не интерпретируются.
+func TextQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`'\:&|!()<>*`, r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		terms = append(terms, "'"+strings.ToLower(w)+"':*")
+	}
+	return strings.Join(terms, " & ")
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/money"
+	"gorm.io/gorm"
+)
+
+// StatsFilter — период [From, To) и необязательный фильтр по валюте
+type StatsFilter struct {
+	From     time.Time
+	To       time.Time
+	Currency string
+	Limit    int
+}
+
+type StatsRepositoryInterface interface {
+	Summary(filter StatsFilter) (*models.StatsSummary, error)
+	// Aggregate группирует заказы по разрезу (models.StatsBy*)
+	Aggregate(dimension string, filter StatsFilter) ([]models.StatsBucket, error)
+}
+
+// StatsRepository выполняет агрегирующие запросы на репликах, если они есть.
+// Без фильтра по валюте выручка суммируется по payment.amount_normalized
+// в валюте отчётности: суммы в разных валютах складывать нельзя.
+type StatsRepository struct {
+	router    *DBRouter
+	reporting string
+}
+
+func NewStatsRepository(router *DBRouter, reportingCurrency string) StatsRepositoryInterface {
+	return &StatsRepository{router: router, reporting: reportingCurrency}
+}
+
+// statsRow — строка агрегата до перевода выручки в money.Money
+type statsRow struct {
+	Key         string
+	Orders      int64
+	Items       int64
+	Revenue     int64
+	Unconverted int64
+}
+
+// orderDimensions — выражения группировки по заказу; ключи — белый список разрезов
+var orderDimensions = map[string]string{
+	models.StatsByDay:             "to_char(o.date_created AT TIME ZONE 'UTC', 'YYYY-MM-DD')",
+	models.StatsByDeliveryService: "o.delivery_service",
+	models.StatsByProvider:        "p.provider",
+	models.StatsByBank:            "p.bank",
+	models.StatsByCurrency:        "p.currency",
+	models.StatsByRegion:          "d.region",
+	models.StatsByCity:            "d.city",
+}
+
+// rollupDimensions — разрезы, которые есть в order_rollups_daily
+var rollupDimensions = map[string]string{
+	models.StatsByDay:             "to_char(day, 'YYYY-MM-DD')",
+	models.StatsByDeliveryService: "delivery_service",
+	models.StatsByProvider:        "provider",
+	models.StatsByCurrency:        "currency",
+}
+
+// itemDimensions — выражения группировки по товарам (топы)
+var itemDimensions = map[string]string{
+	models.StatsByBrand: "i.brand",
+	models.StatsByNMID:  "i.nm_id::text",
+}
+
+// IsStatsDimension сообщает, поддерживается ли разрез
+func IsStatsDimension(dimension string) bool {
+	_, order := orderDimensions[dimension]
+	_, item := itemDimensions[dimension]
+	return order || item
+}
+
+func (r *StatsRepository) Summary(filter StatsFilter) (*models.StatsSummary, error) {
+	query := r.ordersQuery(filter).Select("COUNT(*) AS orders, " + r.ordersRevenue(filter))
+	if dayAligned(filter) {
+		query = r.rollupsQuery(filter).Select("COALESCE(SUM(orders), 0) AS orders, " + r.rollupsRevenue(filter))
+	}
+	var row statsRow
+	if err := query.Row().Scan(&row.Orders, &row.Revenue, &row.Unconverted); err != nil {
+		return nil, err
+	}
+	revenue, err := r.revenue(row.Revenue, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &models.StatsSummary{
+		From:        filter.From,
+		To:          filter.To,
+		Orders:      row.Orders,
+		Revenue:     revenue,
+		Unconverted: row.Unconverted,
+	}, nil
+}
+
+func (r *StatsRepository) Aggregate(dimension string, filter StatsFilter) ([]models.StatsBucket, error) {
+	var (
+		query *gorm.DB
+		rows  []statsRow
+	)
+	switch {
+	// Период из целых дней по разрезам итогов считается по order_rollups_daily
+	case rollupDimensions[dimension] != "" && dayAligned(filter):
+		query = r.rollupsQuery(filter).
+			Select(rollupDimensions[dimension] + " AS key, SUM(orders) AS orders, " + r.rollupsRevenue(filter)).
+			Group("key").
+			Having("SUM(orders) > 0")
+	case orderDimensions[dimension] != "":
+		query = r.ordersQuery(filter).
+			Select(orderDimensions[dimension] + " AS key, COUNT(*) AS orders, " + r.ordersRevenue(filter)).
+			Group("key")
+	case itemDimensions[dimension] != "":
+		query = r.router.Reader("").
+			Table("items AS i").
+			Joins("JOIN orders o ON o.order_uid = i.order_id AND o.date_created = i.date_created").
+			Joins("JOIN payment p ON p.order_id = o.order_uid").
+			Where("o.deleted_at IS NULL AND o.date_created >= ? AND o.date_created < ?", filter.From, filter.To).
+			Select(itemDimensions[dimension] + " AS key, COUNT(DISTINCT i.order_id) AS orders, COUNT(*) AS items, " + r.itemsRevenue(filter)).
+			Group("key")
+		if filter.Currency != "" {
+			query = query.Where("p.currency = ?", filter.Currency)
+		}
+	default:
+		return nil, fmt.Errorf("unknown stats dimension %q", dimension)
+	}
+
+	if dimension == models.StatsByDay {
+		query = query.Order("key")
+	} else {
+		query = query.Order("revenue DESC, key")
+	}
+	if err := query.Limit(filter.Limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	buckets := make([]models.StatsBucket, len(rows))
+	for i, row := range rows {
+		revenue, err := r.revenue(row.Revenue, filter)
+		if err != nil {
+			return nil, err
+		}
+		buckets[i] = models.StatsBucket{Key: row.Key, Orders: row.Orders, Items: row.Items, Revenue: revenue, Unconverted: row.Unconverted}
+	}
+	return buckets, nil
+}
+
+// dayAligned — границы периода приходятся на полночь UTC, итоги по дням к нему применимы
+func dayAligned(filter StatsFilter) bool {
+	return filter.From.Equal(filter.From.Truncate(24*time.Hour)) && filter.To.Equal(filter.To.Truncate(24*time.Hour))
+}
+
+// revenue переводит сумму из запроса в money.Money: с фильтром по валюте это
+// целые единицы этой валюты (payment.amount), без него — минимальные единицы
+// валюты отчётности
+func (r *StatsRepository) revenue(sum int64, filter StatsFilter) (money.Money, error) {
+	if filter.Currency != "" {
+		return money.FromMajor(sum, filter.Currency)
+	}
+	return money.New(sum, r.reporting)
+}
+
+// ordersRevenue — выражения revenue и unconverted по строкам payment
+func (r *StatsRepository) ordersRevenue(filter StatsFilter) string {
+	if filter.Currency != "" {
+		return "COALESCE(SUM(p.amount), 0) AS revenue, 0 AS unconverted"
+	}
+	reporting := quoteLiteral(r.reporting)
+	return "COALESCE(SUM(p.amount_normalized) FILTER (WHERE p.normalized_currency = " + reporting + "), 0) AS revenue, " +
+		"COUNT(*) FILTER (WHERE p.normalized_currency <> " + reporting + ") AS unconverted"
+}
+
+// rollupsRevenue — выражения revenue и unconverted по order_rollups_daily
+func (r *StatsRepository) rollupsRevenue(filter StatsFilter) string {
+	if filter.Currency != "" {
+		return "COALESCE(SUM(revenue), 0) AS revenue, 0 AS unconverted"
+	}
+	return "COALESCE(SUM(revenue_normalized), 0) AS revenue, COALESCE(SUM(unconverted), 0) AS unconverted"
+}
+
+// itemsRevenue — выручка по товарам; без фильтра по валюте total_price
+// пересчитывается в валюту отчётности пропорционально сумме оплаты
+func (r *StatsRepository) itemsRevenue(filter StatsFilter) string {
+	if filter.Currency != "" {
+		return "COALESCE(SUM(i.total_price), 0) AS revenue, 0 AS unconverted"
+	}
+	reporting := quoteLiteral(r.reporting)
+	return "COALESCE(SUM(ROUND(i.total_price::numeric * p.amount_normalized / NULLIF(p.amount, 0))) " +
+		"FILTER (WHERE p.normalized_currency = " + reporting + "), 0)::bigint AS revenue, " +
+		"COUNT(DISTINCT i.order_id) FILTER (WHERE p.normalized_currency <> " + reporting + ") AS unconverted"
+}
+
+// quoteLiteral — строковый литерал SQL; код валюты приходит из конфигурации
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// rollupsQuery — дневные итоги за период
+func (r *StatsRepository) rollupsQuery(filter StatsFilter) *gorm.DB {
+	query := r.router.Reader("").
+		Model(&models.DailyRollup{}).
+		Where("day >= ?::date AND day < ?::date", filter.From.UTC().Format(time.DateOnly), filter.To.UTC().Format(time.DateOnly))
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+	return query
+}
+
+// ordersQuery — заказы за период с оплатой и доставкой
+func (r *StatsRepository) ordersQuery(filter StatsFilter) *gorm.DB {
+	query := r.router.Reader("").
+		Table("orders AS o").
+		Joins("JOIN payment p ON p.order_id = o.order_uid").
+		Joins("LEFT JOIN delivery d ON d.order_id = o.order_uid").
+		Where("o.deleted_at IS NULL AND o.date_created >= ? AND o.date_created < ?", filter.From, filter.To)
+	if filter.Currency != "" {
+		query = query.Where("p.currency = ?", filter.Currency)
+	}
+	return query
+}
@@ -0,0 +1,182 @@
+package retention
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/cache"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+)
+
+// Policy — политика хранения заказов
+type Policy struct {
+	HotDays   int           // сколько дней заказ живёт в основных таблицах; 0 — архивация выключена
+	Storage   string        // models.ArchiveStorageTable или models.ArchiveStorageFile
+	Dir       string        // каталог для файлового архива
+	Interval  time.Duration // как часто запускать архивацию
+	BatchSize int
+}
+
+// Archiver переносит старые заказы в архив и находит их по запросу
+type Archiver struct {
+	repo   repository.ArchiveRepositoryInterface
+	cache  cache.OrderCacheInterface
+	policy Policy
+	files  *fileStore
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewArchiver(repo repository.ArchiveRepositoryInterface, cache cache.OrderCacheInterface, policy Policy) *Archiver {
+	if policy.BatchSize <= 0 {
+		policy.BatchSize = 500
+	}
+	if policy.Storage != models.ArchiveStorageFile {
+		policy.Storage = models.ArchiveStorageTable
+	}
+	return &Archiver{
+		repo:   repo,
+		cache:  cache,
+		policy: policy,
+		files:  &fileStore{dir: policy.Dir},
+		stop:   make(chan struct{}),
+	}
+}
+
+func (a *Archiver) Start() {
+	if a.policy.HotDays <= 0 {
+		return
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		ticker := time.NewTicker(a.policy.Interval)
+		defer ticker.Stop()
+
+		for {
+			if n, err := a.RunOnce(); err != nil {
+				log.Printf("❌ Retention: %v", err)
+			} else if n > 0 {
+				log.Printf("📦 Retention: заархивировано заказов: %d", n)
+			}
+
+			select {
+			case <-a.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce архивирует все заказы старше HotDays и возвращает их количество
+func (a *Archiver) RunOnce() (int, error) {
+	cutoff := time.Now().AddDate(0, 0, -a.policy.HotDays)
+	total := 0
+	for {
+		var uids []string
+		n, err := a.repo.ArchiveBefore(cutoff, a.policy.BatchSize, func(orders []models.ArchivedOrder) ([]models.ArchiveRecord, error) {
+			uids = uids[:0]
+			for _, o := range orders {
+				uids = append(uids, o.Order.OrderUID)
+			}
+			return a.store(orders)
+		})
+		if err != nil {
+			return total, err
+		}
+
+		for _, uid := range uids {
+			_ = a.cache.Delete(uid)
+		}
+		total += n
+		if n < a.policy.BatchSize {
+			return total, nil
+		}
+	}
+}
+
+// store сохраняет снимки заказов и возвращает строки индекса архива.
+// Персональные данные в снимках зашифрованы так же, как в столбцах БД.
+func (a *Archiver) store(snapshots []models.ArchivedOrder) ([]models.ArchiveRecord, error) {
+	orders := make([]models.ArchivedOrder, len(snapshots))
+	for i, snapshot := range snapshots {
+		sealed, err := snapshot.Order.SealPII()
+		if err != nil {
+			return nil, err
+		}
+		orders[i] = models.ArchivedOrder{Order: sealed, History: snapshot.History}
+	}
+
+	var locations map[string]string
+	if a.policy.Storage == models.ArchiveStorageFile {
+		var err error
+		if locations, err = a.files.write(orders); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	records := make([]models.ArchiveRecord, 0, len(orders))
+	for i := range orders {
+		order := orders[i].Order
+		record := models.ArchiveRecord{
+			OrderUID:    order.OrderUID,
+			TrackNumber: order.TrackNumber,
+			CustomerID:  order.CustomerID,
+			DateCreated: order.DateCreated.Time,
+			Storage:     a.policy.Storage,
+			ArchivedAt:  now,
+			DeletedAt:   order.DeletedAt,
+		}
+
+		if a.policy.Storage == models.ArchiveStorageFile {
+			record.Location = locations[order.OrderUID]
+		} else {
+			payload, err := json.Marshal(&orders[i])
+			if err != nil {
+				return nil, err
+			}
+			record.Payload = payload
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// FindArchived достаёт заказ из архива (таблицы или файла)
+func (a *Archiver) FindArchived(orderUID string) (*models.Order, error) {
+	record, err := a.repo.FindArchived(orderUID)
+	if err != nil {
+		return nil, err
+	}
+
+	var archived *models.ArchivedOrder
+	switch record.Storage {
+	case models.ArchiveStorageFile:
+		if archived, err = a.files.read(record.Location, orderUID); err != nil {
+			return nil, err
+		}
+	case models.ArchiveStorageTable:
+		archived = &models.ArchivedOrder{}
+		if err := json.Unmarshal(record.Payload, archived); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown archive storage %q", record.Storage)
+	}
+	return archived.Order.OpenPII()
+}
+
+func (a *Archiver) Close() error {
+	close(a.stop)
+	a.wg.Wait()
+	return nil
+}
@@ -0,0 +1,204 @@
+package retention
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+)
+
+// fileStore складывает архив в сжатые JSONL-файлы, по одному на месяц date_created.
+// Каждая пачка дописывается отдельным gzip-member, gzip.Reader читает их подряд.
+type fileStore struct {
+	dir string
+}
+
+func (s *fileStore) write(orders []models.ArchivedOrder) (map[string]string, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	byFile := make(map[string][]models.ArchivedOrder)
+	for _, o := range orders {
+		name := "orders-unknown.jsonl.gz"
+		if created := o.Order.DateCreated; !created.IsZero() {
+			name = fmt.Sprintf("orders-%s.jsonl.gz", created.UTC().Format("2006-01"))
+		}
+		byFile[name] = append(byFile[name], o)
+	}
+
+	locations := make(map[string]string, len(orders))
+	for name, batch := range byFile {
+		path := filepath.Join(s.dir, name)
+		if err := appendGzipJSONL(path, batch); err != nil {
+			return nil, fmt.Errorf("write archive %s: %w", path, err)
+		}
+		for _, o := range batch {
+			locations[o.Order.OrderUID] = name
+		}
+	}
+	return locations, nil
+}
+
+// read находит заказ в архивном файле
+func (s *fileStore) read(location, orderUID string) (*models.ArchivedOrder, error) {
+	f, err := os.Open(filepath.Join(s.dir, filepath.Base(location)))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var probe struct {
+			Order struct {
+				OrderUID string `json:"order_uid"`
+			} `json:"order"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &probe); err != nil || probe.Order.OrderUID != orderUID {
+			continue
+		}
+
+		var archived models.ArchivedOrder
+		if err := json.Unmarshal(scanner.Bytes(), &archived); err != nil {
+			return nil, err
+		}
+		return &archived, nil
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	return nil, fmt.Errorf("order %s not found in %s", orderUID, location)
+}
+
+func appendGzipJSONL(path string, orders []models.ArchivedOrder) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	for i := range orders {
+		if err := enc.Encode(&orders[i]); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	// Строки в БД удаляются только после того, как архив гарантированно на диске
+	return f.Sync()
+}
+
+// ReencryptFiles приводит персональные данные во всех файлах архива dir к активному
+// ключу ring и возвращает число изменённых записей. Файл с изменениями пишется
+// заново во временный и подменяется через rename; на время обхода каталог
+// заблокирован, и архивация не допишет пачку в подменяемый файл.
+func ReencryptFiles(dir string, ring *pii.Keyring) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl.gz"))
+	if err != nil || len(paths) == 0 {
+		return 0, err
+	}
+	unlock, err := lockDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	total := 0
+	for _, path := range paths {
+		n, err := reencryptFile(path, ring)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return total, nil
+}
+
+func reencryptFile(path string, ring *pii.Keyring) (int, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	zr, err := gzip.NewReader(src)
+	if err != nil {
+		return 0, err
+	}
+	defer zr.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".reencrypt-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	zw := gzip.NewWriter(tmp)
+
+	changed := 0
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		rotated, err := models.MapSnapshotPII(line, func(value string) (string, error) {
+			rotated, _, err := ring.Rotate(value)
+			return rotated, err
+		})
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.Equal(rotated, line) {
+			changed++
+		}
+		if _, err := zw.Write(rotated); err != nil {
+			return 0, err
+		}
+		if _, err := zw.Write([]byte{'\n'}); err != nil {
+			return 0, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		return 0, err
+	}
+	if err := tmp.Sync(); err != nil {
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	return changed, os.Rename(tmp.Name(), path)
+}
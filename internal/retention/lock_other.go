@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package retention
+
+// lockDir без flock: reencrypt нельзя запускать параллельно с архивацией в файлы
+func lockDir(dir string) (unlock func(), err error) {
+	return func() {}, nil
+}
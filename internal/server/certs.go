@@ -0,0 +1,106 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertReloader отдаёт TLS-сертификат и перечитывает его с диска, когда меняются
+// файлы (например, после продления certbot). Если новые файлы не читаются,
+// продолжает отдавать прежний сертификат.
+type CertReloader struct {
+	certFile, keyFile string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewCertReloader загружает сертификат и проверяет файлы раз в interval (0 — не проверять)
+func NewCertReloader(certFile, keyFile string, interval time.Duration) (*CertReloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both TLS certificate and key files are required")
+	}
+	c := &CertReloader{certFile: certFile, keyFile: keyFile, stop: make(chan struct{})}
+	if _, err := c.Reload(); err != nil {
+		return nil, err
+	}
+	if interval > 0 {
+		go c.watch(interval)
+	}
+	return c, nil
+}
+
+func (c *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// Reload перечитывает сертификат, если файлы изменились; true — сертификат заменён
+func (c *CertReloader) Reload() (bool, error) {
+	modTime, err := c.latestModTime()
+	if err != nil {
+		return false, err
+	}
+	c.mu.RLock()
+	unchanged := c.cert != nil && modTime.Equal(c.modTime)
+	c.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("load TLS certificate: %w", err)
+	}
+	c.mu.Lock()
+	c.cert, c.modTime = &cert, modTime
+	c.mu.Unlock()
+	return true, nil
+}
+
+// latestModTime — время изменения более нового из двух файлов
+func (c *CertReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (c *CertReloader) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			reloaded, err := c.Reload()
+			switch {
+			case err != nil:
+				log.Printf("⚠️ TLS certificate reload failed, keeping the current one: %v", err)
+			case reloaded:
+				log.Printf("🔐 TLS certificate reloaded from %s", c.certFile)
+			}
+		}
+	}
+}
+
+func (c *CertReloader) Close() {
+	c.once.Do(func() { close(c.stop) })
+}
@@ -0,0 +1,114 @@
+// Package server — HTTP-сервер API с таймаутами, лимитом заголовков,
+// необязательным TLS (сертификат перечитывается с диска без перезапуска) и HTTP/2.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+type Options struct {
+	Addr string
+
+	// ReadHeaderTimeout ограничивает чтение заголовков (защита от slowloris),
+	// ReadTimeout — чтение всего запроса, WriteTimeout — запись ответа,
+	// IdleTimeout — простой keep-alive соединения
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+
+	// TLS: пустые пути — сервер без TLS. Файлы проверяются раз в CertReloadInterval
+	// и перечитываются при изменении.
+	TLSCertFile        string
+	TLSKeyFile         string
+	CertReloadInterval time.Duration
+
+	// H2C разрешает HTTP/2 без TLS (за прокси, который сам терминирует TLS)
+	H2C bool
+}
+
+// Server — http.Server с перезагрузкой сертификата
+type Server struct {
+	srv   *http.Server
+	certs *CertReloader
+}
+
+func New(handler http.Handler, opts Options) (*Server, error) {
+	srv := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		ReadTimeout:       opts.ReadTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+		Protocols:         new(http.Protocols),
+	}
+	srv.Protocols.SetHTTP1(true)
+	srv.Protocols.SetHTTP2(true)
+	srv.Protocols.SetUnencryptedHTTP2(opts.H2C)
+
+	s := &Server{srv: srv}
+	if opts.TLSCertFile != "" || opts.TLSKeyFile != "" {
+		certs, err := NewCertReloader(opts.TLSCertFile, opts.TLSKeyFile, opts.CertReloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		s.certs = certs
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+	}
+	return s, nil
+}
+
+// TLS сообщает, обслуживает ли сервер HTTPS
+func (s *Server) TLS() bool {
+	return s.certs != nil
+}
+
+// Run обслуживает запросы до отмены ctx, затем ждёт завершения текущих
+// запросов не дольше shutdownTimeout
+func (s *Server) Run(ctx context.Context, shutdownTimeout time.Duration) error {
+	errc := make(chan error, 1)
+	go func() {
+		var err error
+		if s.certs != nil {
+			// Сертификат отдаёт TLSConfig.GetCertificate
+			err = s.srv.ListenAndServeTLS("", "")
+		} else {
+			err = s.srv.ListenAndServe()
+		}
+		errc <- err
+	}()
+
+	select {
+	case err := <-errc:
+		s.closeCerts()
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("🛑 Shutting down HTTP server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := s.srv.Shutdown(shutdownCtx)
+	s.closeCerts()
+	if srvErr := <-errc; !errors.Is(srvErr, http.ErrServerClosed) && err == nil {
+		err = srvErr
+	}
+	return err
+}
+
+func (s *Server) closeCerts() {
+	if s.certs != nil {
+		s.certs.Close()
+	}
+}
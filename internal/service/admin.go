@@ -0,0 +1,40 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+)
+
+// CancelOrder отменяет заказ (переход в cancelled с записью в историю) и мягко
+// удаляет его. Уже отменённый заказ только удаляется.
+func (s *OrderService) CancelOrder(orderUID, reason string) error {
+	current, err := s.repo.FindByOrderUID(orderUID)
+	if err != nil {
+		return storageError(err)
+	}
+	if current.Status != models.StatusCancelled {
+		if _, err := s.ChangeStatus(orderUID, models.StatusCancelled, reason, time.Now()); err != nil {
+			return err
+		}
+	}
+	return s.DeleteOrder(orderUID, reason)
+}
+
+// DeleteOrder мягко удаляет заказ: он пропадает из выдачи, статус не меняется
+func (s *OrderService) DeleteOrder(orderUID, reason string) error {
+	if err := s.repo.SoftDelete(orderUID, time.Now()); err != nil {
+		return storageError(err)
+	}
+	log.Printf("🗑️ Order %s deleted: %s", orderUID, reason)
+	return s.cache.Delete(orderUID)
+}
+
+// PurgeOrder безвозвратно удаляет заказ вместе с доставкой, оплатой и товарами
+func (s *OrderService) PurgeOrder(orderUID string) error {
+	if err := s.repo.Purge(orderUID); err != nil {
+		return storageError(err)
+	}
+	return s.cache.Delete(orderUID)
+}
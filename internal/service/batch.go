@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/google/uuid"
+)
+
+// MaxBatchGetSize ограничивает число UID в одном пакетном чтении
+const MaxBatchGetSize = 500
+
+// GetOrdersByUIDs читает заказы пакетом: попадания — одним MGET из кеша, промахи —
+// одним запросом к БД, оставшиеся — из архива. Найденные заказы возвращаются
+// в порядке запроса, повторы UID схлопываются.
+func (s *OrderService) GetOrdersByUIDs(orderUIDs []string) (found []models.Order, missing []string, err error) {
+	v := &validator{}
+	if len(orderUIDs) == 0 {
+		v.add("order_uids", "must contain at least one order_uid")
+	}
+	if len(orderUIDs) > MaxBatchGetSize {
+		v.add("order_uids", fmt.Sprintf("must contain at most %d order_uids", MaxBatchGetSize))
+	}
+	for i, uid := range orderUIDs {
+		if _, err := uuid.Parse(uid); err != nil {
+			v.add(fmt.Sprintf("order_uids[%d]", i), "must be a UUID")
+		}
+	}
+	if err := v.err(); err != nil {
+		return nil, nil, err
+	}
+
+	uids := make([]string, 0, len(orderUIDs))
+	seen := make(map[string]bool, len(orderUIDs))
+	for _, uid := range orderUIDs {
+		if !seen[uid] {
+			seen[uid] = true
+			uids = append(uids, uid)
+		}
+	}
+
+	orders, err := s.cache.GetMany(uids)
+	if err != nil {
+		orders = make(map[string]*models.Order, len(uids))
+	}
+
+	var misses []string
+	for _, uid := range uids {
+		if orders[uid] == nil {
+			misses = append(misses, uid)
+		}
+	}
+
+	if len(misses) > 0 {
+		loaded, err := s.repo.FindByOrderUIDs(misses)
+		if err != nil {
+			return nil, nil, storageError(err)
+		}
+		for i := range loaded {
+			order := &loaded[i]
+			orders[order.OrderUID] = order
+			_ = s.cache.Set(order)
+		}
+	}
+
+	for _, uid := range uids {
+		if orders[uid] != nil {
+			continue
+		}
+		if s.archive != nil {
+			if archived, err := s.archive.FindArchived(uid); err == nil {
+				orders[uid] = archived
+				_ = s.cache.Set(archived)
+				continue
+			}
+		}
+		missing = append(missing, uid)
+	}
+
+	found = make([]models.Order, 0, len(uids)-len(missing))
+	for _, uid := range uids {
+		if order := orders[uid]; order != nil {
+			found = append(found, *order)
+		}
+	}
+	return found, missing, nil
+}
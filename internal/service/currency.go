@@ -0,0 +1,49 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/money"
+)
+
+// SetRates включает пересчёт сумм оплаты в валюту отчётности reporting
+func (s *OrderService) SetRates(rates *money.RateTable, reporting string) {
+	s.rates, s.reportingCurrency = rates, reporting
+}
+
+// normalizeAmounts пересчитывает суммы оплаты по курсу на дату оплаты.
+// Если курса нет, заказ сохраняется без пересчёта: это не повод терять заказ.
+func (s *OrderService) normalizeAmounts(p *models.Payment) {
+	p.Normalized = nil
+	if s.rates == nil {
+		return
+	}
+
+	at := p.PaymentDt.Time
+	normalized := &models.NormalizedPayment{}
+	for _, field := range []struct {
+		value int
+		dst   *money.Money
+	}{
+		{p.Amount, &normalized.Amount},
+		{p.DeliveryCost, &normalized.DeliveryCost},
+		{p.GoodsTotal, &normalized.GoodsTotal},
+		{p.CustomFee, &normalized.CustomFee},
+	} {
+		amount, err := money.FromMajor(int64(field.value), p.Currency)
+		if err != nil {
+			log.Printf("⚠️  Payment %s not normalized: %v", p.Transaction, err)
+			return
+		}
+		converted, rateDate, err := s.rates.Convert(amount, s.reportingCurrency, at)
+		if err != nil {
+			log.Printf("⚠️  Payment %s not normalized: %v", p.Transaction, err)
+			return
+		}
+		*field.dst = converted
+		normalized.RateDate = rateDate.Format(time.DateOnly)
+	}
+	p.Normalized = normalized
+}
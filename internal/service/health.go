@@ -0,0 +1,44 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/breaker"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/consumer"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+)
+
+// ErrUnavailable — БД недоступна: сбой соединения, таймаут или разомкнутый предохранитель
+var ErrUnavailable = errors.New("storage unavailable")
+
+// HealthState сообщает, работает ли сервис в деградированном режиме
+type HealthState interface {
+	Degraded() bool
+}
+
+// SetHealth подключает источник состояния деградированного режима
+func (s *OrderService) SetHealth(health HealthState) {
+	s.health = health
+}
+
+// Degraded — БД недоступна, заказы отдаются только из кеша, приём приостановлен
+func (s *OrderService) Degraded() bool {
+	return s.health != nil && s.health.Degraded()
+}
+
+// storageError помечает отказ БД как ErrUnavailable, остальные ошибки не трогает
+func storageError(err error) error {
+	if errors.Is(err, breaker.ErrOpen) || repository.IsInfrastructureError(err) {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return err
+}
+
+// retryable просит источник повторить сообщение позже, если БД недоступна
+func retryable(err error) error {
+	if errors.Is(err, ErrUnavailable) {
+		return fmt.Errorf("%w: %w", consumer.ErrRetry, err)
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+)
+
+// GetOrderByTrackNumber ищет заказ по трек-номеру: сначала по вторичному ключу в кеше, затем в БД
+func (s *OrderService) GetOrderByTrackNumber(trackNumber string) (*models.Order, error) {
+	return s.GetOrderByTrackNumberWith(trackNumber, repository.IncludeAll)
+}
+
+// GetOrderByTrackNumberWith — то же, но при промахе кеша из БД загружаются только
+// ассоциации из include; неполный заказ в кеш не попадает
+func (s *OrderService) GetOrderByTrackNumberWith(trackNumber string, include repository.Include) (*models.Order, error) {
+	if order, err := s.cache.GetByTrackNumber(trackNumber); err == nil {
+		return order, nil
+	}
+
+	order, err := s.repo.FindByTrackNumber(trackNumber, include)
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	if include == repository.IncludeAll {
+		_ = s.cache.Set(order)
+	}
+	return order, nil
+}
+
+// GetOrderByTransaction ищет заказ по идентификатору платёжной транзакции
+func (s *OrderService) GetOrderByTransaction(transaction string) (*models.Order, error) {
+	return s.GetOrderByTransactionWith(transaction, repository.IncludeAll)
+}
+
+// GetOrderByTransactionWith — то же с загрузкой только ассоциаций из include
+func (s *OrderService) GetOrderByTransactionWith(transaction string, include repository.Include) (*models.Order, error) {
+	if order, err := s.cache.GetByTransaction(transaction); err == nil {
+		return order, nil
+	}
+
+	order, err := s.repo.FindByTransaction(transaction, include)
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	if include == repository.IncludeAll {
+		_ = s.cache.Set(order)
+	}
+	return order, nil
+}
+
+// GetCustomerOrders возвращает страницу заказов клиента (новые первыми) и их общее число.
+// Список order_uid кешируется целиком, сами заказы читаются пакетом через кеш.
+func (s *OrderService) GetCustomerOrders(customerID string, limit, offset int) ([]models.Order, int, error) {
+	v := &validator{}
+	if limit < 0 || limit > MaxPageLimit {
+		v.add("limit", "must be between 1 and 100")
+	}
+	if offset < 0 {
+		v.add("offset", "must not be negative")
+	}
+	if err := v.err(); err != nil {
+		return nil, 0, err
+	}
+	if limit == 0 {
+		limit = DefaultPageLimit
+	}
+
+	uids, err := s.cache.GetCustomerOrderUIDs(customerID)
+	if err != nil {
+		uids, err = s.repo.FindOrderUIDsByCustomer(customerID)
+		if err != nil {
+			return nil, 0, storageError(err)
+		}
+		_ = s.cache.SetCustomerOrderUIDs(customerID, uids)
+	}
+
+	total := len(uids)
+	page := uids[min(offset, total):min(offset+limit, total)]
+
+	if len(page) == 0 {
+		return []models.Order{}, total, nil
+	}
+	// Заказ мог быть удалён после того, как список попал в кеш: такие пропускаем
+	orders, _, err := s.GetOrdersByUIDs(page)
+	if err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
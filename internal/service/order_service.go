@@ -2,58 +2,126 @@ package service
 
 import (
 	"encoding/json"
-	"time"
+	"errors"
+	"fmt"
 
 	"github.com/Sphirium/wb-tech-demo-lo/internal/cache"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/money"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// ArchiveLookup находит заказы, перенесённые из горячих таблиц в архив
+type ArchiveLookup interface {
+	FindArchived(orderUID string) (*models.Order, error)
+}
+
 type OrderService struct {
-	repo  repository.OrderRepositoryInterface
-	cache cache.OrderCacheInterface
+	repo    repository.OrderRepositoryInterface
+	cache   cache.OrderCacheInterface
+	archive ArchiveLookup
+	health  HealthState
+
+	rates             *money.RateTable
+	reportingCurrency string
 }
 
 func NewOrderService(repo repository.OrderRepositoryInterface, cache cache.OrderCacheInterface) *OrderService {
 	return &OrderService{repo: repo, cache: cache}
 }
 
+// SetArchive включает поиск заказов в архиве, если их нет в основных таблицах
+func (s *OrderService) SetArchive(archive ArchiveLookup) {
+	s.archive = archive
+}
+
+// ErrInvalidPayload — тело сообщения не является JSON-заказом
+var ErrInvalidPayload = errors.New("invalid order payload")
+
+// ErrOrderExists — заказ уже сохранён ранее
+var ErrOrderExists = repository.ErrDuplicate
+
+// SaveOrder — обработчик для источников заказов (Kafka, файл и т.д.)
 func (s *OrderService) SaveOrder(data []byte) error {
+	_, err := s.CreateOrder(data)
+	return retryable(err)
+}
+
+// CreateOrder разбирает, проверяет и сохраняет заказ, возвращая сохранённую версию
+func (s *OrderService) CreateOrder(data []byte) (*models.Order, error) {
 	var order models.Order
 	if err := json.Unmarshal(data, &order); err != nil {
-		return err
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	normalizeOrder(&order)
+	if err := ValidateOrder(&order); err != nil {
+		return nil, err
+	}
+	if order.Payment != nil {
+		s.normalizeAmounts(order.Payment)
 	}
 
+	if err := s.repo.Create(&order); err != nil {
+		return nil, storageError(err)
+	}
+
+	_ = s.cache.Set(&order)
+	_ = s.cache.DeleteCustomerOrderUIDs(order.CustomerID)
+	return &order, nil
+}
+
+// normalizeOrder заполняет производные поля: UID и ссылки ассоциаций на заказ
+func normalizeOrder(order *models.Order) {
 	if order.OrderUID == "" {
 		order.OrderUID = uuid.New().String()
 	}
+	if order.Status == "" {
+		order.Status = models.StatusCreated
+	}
 
 	if order.Delivery != nil {
 		order.Delivery.OrderID = order.OrderUID
 	}
 	if order.Payment != nil {
+		if order.Payment.Transaction == "" {
+			order.Payment.Transaction = order.OrderUID
+		}
 		order.Payment.OrderID = order.OrderUID
-		order.Payment.PaymentDtTime = time.Unix(order.Payment.PaymentDt, 0)
 	}
 	for i := range order.Items {
 		order.Items[i].OrderID = order.OrderUID
+		order.Items[i].DateCreated = order.DateCreated
 	}
-
-	return s.repo.Create(&order)
 }
 
 func (s *OrderService) GetOrderByUID(orderUID string) (*models.Order, error) {
+	return s.GetOrderByUIDWith(orderUID, repository.IncludeAll)
+}
+
+// GetOrderByUIDWith возвращает заказ, в котором нужны только ассоциации из include.
+// Из кеша заказ приходит целиком; при промахе из БД загружается только нужное,
+// и такой неполный заказ в кеш не попадает.
+func (s *OrderService) GetOrderByUIDWith(orderUID string, include repository.Include) (*models.Order, error) {
 	if order, err := s.cache.Get(orderUID); err == nil {
 		return order, nil
 	}
 
-	order, err := s.repo.FindByOrderUID(orderUID)
+	order, err := s.repo.FindByOrderUIDWith(orderUID, include)
+	if errors.Is(err, gorm.ErrRecordNotFound) && s.archive != nil {
+		if archived, archiveErr := s.archive.FindArchived(orderUID); archiveErr == nil {
+			order, err = archived, nil
+		}
+	}
 	if err != nil {
-		return nil, err
+		return nil, storageError(err)
 	}
 
-	_ = s.cache.Set(order)
+	if include == repository.IncludeAll {
+		_ = s.cache.Set(order)
+	}
 	return order, nil
 }
 
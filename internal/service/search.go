@@ -0,0 +1,37 @@
+package service
+
+import (
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+)
+
+// Размер страницы для поиска и списков заказов
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// SearchOrders ищет заказы по тексту и точным полям; незаданный Limit
+// заполняется значением по умолчанию. Результат не кешируется: поиск нужен
+// поддержке, а не горячему пути чтения.
+func (s *OrderService) SearchOrders(search *repository.OrderSearch) ([]models.Order, error) {
+	v := &validator{}
+	if search.Empty() {
+		v.add("q", "at least one search criterion is required")
+	}
+	if search.Limit < 0 || search.Limit > MaxPageLimit {
+		v.add("limit", "must be between 1 and 100")
+	}
+	if search.Offset < 0 {
+		v.add("offset", "must not be negative")
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	if search.Limit == 0 {
+		search.Limit = DefaultPageLimit
+	}
+
+	orders, err := s.repo.Search(*search)
+	return orders, storageError(err)
+}
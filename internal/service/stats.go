@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/cache"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/money"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+)
+
+const (
+	defaultStatsLimit = 20
+	maxStatsLimit     = 1000
+)
+
+// StatsService считает агрегаты по заказам и кеширует их на короткое время
+type StatsService struct {
+	repo  repository.StatsRepositoryInterface
+	cache cache.StatsCacheInterface
+}
+
+func NewStatsService(repo repository.StatsRepositoryInterface, cache cache.StatsCacheInterface) *StatsService {
+	return &StatsService{repo: repo, cache: cache}
+}
+
+// Summary возвращает число заказов и выручку за период
+func (s *StatsService) Summary(filter repository.StatsFilter) (*models.StatsSummary, error) {
+	if err := validateStatsFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	key := statsKey("summary", filter)
+	var summary models.StatsSummary
+	if ok, err := s.cache.Get(key, &summary); err == nil && ok {
+		return &summary, nil
+	}
+
+	result, err := s.repo.Summary(filter)
+	if err != nil {
+		return nil, storageError(err)
+	}
+	_ = s.cache.Set(key, result)
+	return result, nil
+}
+
+// Aggregate группирует заказы за период по разрезу dimension (models.StatsBy*)
+func (s *StatsService) Aggregate(dimension string, filter repository.StatsFilter) ([]models.StatsBucket, error) {
+	if !repository.IsStatsDimension(dimension) {
+		return nil, &ValidationError{Fields: map[string]string{"dimension": "unknown dimension"}}
+	}
+	if err := validateStatsFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	key := statsKey(dimension, filter)
+	var buckets []models.StatsBucket
+	if ok, err := s.cache.Get(key, &buckets); err == nil && ok {
+		return buckets, nil
+	}
+
+	buckets, err := s.repo.Aggregate(dimension, filter)
+	if err != nil {
+		return nil, storageError(err)
+	}
+	if buckets == nil {
+		buckets = []models.StatsBucket{}
+	}
+	_ = s.cache.Set(key, buckets)
+	return buckets, nil
+}
+
+// validateStatsFilter проверяет период и заполняет Limit по умолчанию
+func validateStatsFilter(filter *repository.StatsFilter) error {
+	v := &validator{}
+	if !filter.From.Before(filter.To) {
+		v.add("from", "must be before to")
+	}
+	if filter.Limit < 0 || filter.Limit > maxStatsLimit {
+		v.add("limit", fmt.Sprintf("must be between 1 and %d", maxStatsLimit))
+	}
+	if filter.Currency != "" {
+		if _, err := money.LookupCurrency(filter.Currency); err != nil {
+			v.add("currency", "must be an ISO 4217 code")
+		}
+	}
+	if err := v.err(); err != nil {
+		return err
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultStatsLimit
+	}
+	return nil
+}
+
+func statsKey(dimension string, filter repository.StatsFilter) string {
+	return fmt.Sprintf("%s:%s:%s:%s:%d", dimension,
+		filter.From.UTC().Format(time.RFC3339), filter.To.UTC().Format(time.RFC3339),
+		filter.Currency, filter.Limit)
+}
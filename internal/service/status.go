@@ -0,0 +1,102 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/consumer"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+)
+
+// ErrInvalidTransition — переход между статусами не разрешён
+var ErrInvalidTransition = errors.New("status transition is not allowed")
+
+// ErrStatusConflict — статус изменился параллельно, переход нужно повторить
+var ErrStatusConflict = repository.ErrStatusConflict
+
+// transitions — разрешённые переходы статусов заказа
+var transitions = map[models.OrderStatus][]models.OrderStatus{
+	models.StatusCreated:    {models.StatusPaid, models.StatusCancelled},
+	models.StatusPaid:       {models.StatusAssembling, models.StatusCancelled},
+	models.StatusAssembling: {models.StatusShipped, models.StatusCancelled},
+	models.StatusShipped:    {models.StatusDelivered, models.StatusReturned},
+	models.StatusDelivered:  {models.StatusReturned},
+	models.StatusCancelled:  {},
+	models.StatusReturned:   {},
+}
+
+// CanTransition сообщает, можно ли перевести заказ из from в to
+func CanTransition(from, to models.OrderStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// statusAttempts — сколько раз ChangeStatus перечитывает заказ после конфликта
+const statusAttempts = 3
+
+// ChangeStatus переводит заказ в новый статус с записью в историю. Если статус
+// успел измениться (параллельная запись или устаревшая реплика), заказ
+// перечитывается с primary и переход проверяется заново.
+func (s *OrderService) ChangeStatus(orderUID string, to models.OrderStatus, reason string, at time.Time) (*models.Order, error) {
+	if !to.Valid() {
+		return nil, &ValidationError{Fields: map[string]string{"status": "unknown status"}}
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	for attempt := 1; ; attempt++ {
+		current, err := s.repo.FindByOrderUID(orderUID)
+		if err != nil {
+			return nil, storageError(err)
+		}
+		if !CanTransition(current.Status, to) {
+			return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, current.Status, to)
+		}
+
+		order, err := s.repo.ChangeStatus(orderUID, current.Status, to, reason, at)
+		if errors.Is(err, ErrStatusConflict) && attempt < statusAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, storageError(err)
+		}
+
+		_ = s.cache.Set(order)
+		return order, nil
+	}
+}
+
+// ApplyStatusMessage — обработчик сообщений из топика статусов. Конфликт,
+// не разрешившийся повторами, не фиксирует сообщение: оно придёт снова.
+func (s *OrderService) ApplyStatusMessage(data []byte) error {
+	var msg models.StatusChange
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if msg.OrderUID == "" {
+		return &ValidationError{Fields: map[string]string{"order_uid": "is required"}}
+	}
+
+	_, err := s.ChangeStatus(msg.OrderUID, msg.Status, msg.Reason, msg.ChangedAt)
+	if errors.Is(err, ErrStatusConflict) {
+		return fmt.Errorf("%w: %w", consumer.ErrRetry, err)
+	}
+	return retryable(err)
+}
+
+// GetStatusHistory возвращает историю статусов заказа в хронологическом порядке
+func (s *OrderService) GetStatusHistory(orderUID string) ([]models.StatusHistory, error) {
+	if _, err := s.GetOrderByUID(orderUID); err != nil {
+		return nil, err
+	}
+	history, err := s.repo.GetStatusHistory(orderUID)
+	return history, storageError(err)
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/money"
+	"github.com/google/uuid"
+)
+
+// ValidationError — заказ не прошёл проверку; Fields: поле -> причина
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+": "+e.Fields[k])
+	}
+	return "invalid order: " + strings.Join(parts, "; ")
+}
+
+// validator накапливает ошибки по полям
+type validator struct {
+	fields map[string]string
+}
+
+func (v *validator) add(field, reason string) {
+	if v.fields == nil {
+		v.fields = make(map[string]string)
+	}
+	if _, exists := v.fields[field]; !exists {
+		v.fields[field] = reason
+	}
+}
+
+// str проверяет обязательную строку и её максимальную длину (как в миграции)
+func (v *validator) str(field, value string, required bool, max int) {
+	if required && strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+		return
+	}
+	if max > 0 && len(value) > max {
+		v.add(field, fmt.Sprintf("must be at most %d characters", max))
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+// ValidateOrder проверяет заказ перед сохранением
+func ValidateOrder(order *models.Order) error {
+	v := &validator{}
+
+	// order_uid и payment.transaction в схеме — столбцы UUID: иначе заказ не сохранится
+	if _, err := uuid.Parse(order.OrderUID); err != nil {
+		v.add("order_uid", "must be a UUID")
+	}
+	v.str("track_number", order.TrackNumber, true, 64)
+	v.str("entry", order.Entry, true, 10)
+	v.str("locale", order.Locale, true, 10)
+	v.str("customer_id", order.CustomerID, true, 128)
+	v.str("delivery_service", order.DeliveryService, true, 64)
+	v.str("shardkey", order.Shardkey, true, 2)
+	v.str("oof_shard", order.OofShard, true, 2)
+	if !order.Status.Valid() {
+		v.add("status", "unknown status")
+	}
+	if order.DateCreated.IsZero() {
+		v.add("date_created", "is required")
+	}
+
+	validateDelivery(v, order.Delivery)
+	validatePayment(v, order.Payment)
+
+	if len(order.Items) == 0 {
+		v.add("items", "must contain at least one item")
+	}
+	for i := range order.Items {
+		validateItem(v, fmt.Sprintf("items[%d]", i), &order.Items[i])
+	}
+
+	return v.err()
+}
+
+func validateDelivery(v *validator, d *models.Delivery) {
+	if d == nil {
+		v.add("delivery", "is required")
+		return
+	}
+	v.str("delivery.name", d.Name, true, 128)
+	v.str("delivery.phone", d.Phone, true, 15)
+	v.str("delivery.zip", d.Zip, true, 10)
+	v.str("delivery.city", d.City, true, 64)
+	v.str("delivery.address", d.Address, true, 64)
+	v.str("delivery.region", d.Region, true, 64)
+	v.str("delivery.email", d.Email, false, 128)
+	if d.Email != "" {
+		if _, err := mail.ParseAddress(d.Email); err != nil {
+			v.add("delivery.email", "must be a valid email address")
+		}
+	}
+}
+
+func validatePayment(v *validator, p *models.Payment) {
+	if p == nil {
+		v.add("payment", "is required")
+		return
+	}
+	if _, err := uuid.Parse(p.Transaction); err != nil {
+		v.add("payment.transaction", "must be a UUID")
+	}
+	v.str("payment.request_id", p.RequestID, false, 64)
+	if _, err := money.LookupCurrency(p.Currency); err != nil {
+		v.add("payment.currency", "must be an ISO 4217 currency code")
+	}
+	v.str("payment.provider", p.Provider, true, 32)
+	v.str("payment.bank", p.Bank, true, 20)
+	if p.Amount < 0 {
+		v.add("payment.amount", "must not be negative")
+	}
+	if p.DeliveryCost < 0 {
+		v.add("payment.delivery_cost", "must not be negative")
+	}
+	if p.GoodsTotal < 0 {
+		v.add("payment.goods_total", "must not be negative")
+	}
+	if p.CustomFee < 0 {
+		v.add("payment.custom_fee", "must not be negative")
+	}
+	if p.PaymentDt.IsZero() {
+		v.add("payment.payment_dt", "is required")
+	}
+}
+
+func validateItem(v *validator, prefix string, it *models.Item) {
+	if it.ChrtID <= 0 {
+		v.add(prefix+".chrt_id", "must be positive")
+	}
+	if it.NMID <= 0 {
+		v.add(prefix+".nm_id", "must be positive")
+	}
+	v.str(prefix+".track_number", it.TrackNumber, true, 64)
+	v.str(prefix+".rid", it.RID, true, 64)
+	v.str(prefix+".name", it.Name, true, 128)
+	v.str(prefix+".size", it.Size, true, 16)
+	v.str(prefix+".brand", it.Brand, true, 64)
+	if it.Sale < 0 || it.Sale > 100 {
+		v.add(prefix+".sale", "must be between 0 and 100")
+	}
+	if it.Price < 0 {
+		v.add(prefix+".price", "must not be negative")
+	}
+	if it.TotalPrice < 0 {
+		v.add(prefix+".total_price", "must not be negative")
+	}
+}
@@ -0,0 +1,17 @@
+-- Архив заказов старше срока хранения горячих данных.
+-- payload хранит заказ с доставкой, оплатой, товарами и историей статусов;
+-- для хранения в файлах payload пуст, а location указывает на .jsonl.gz
+CREATE TABLE IF NOT EXISTS orders_archive (
+    order_uid UUID PRIMARY KEY,
+    track_number VARCHAR(64) NOT NULL,
+    customer_id VARCHAR(128) NOT NULL,
+    date_created TIMESTAMP NOT NULL,
+    storage VARCHAR(8) NOT NULL,
+    location TEXT NOT NULL DEFAULT '',
+    payload JSONB,
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Индексы
+CREATE INDEX IF NOT EXISTS idx_orders_archive_date_created ON orders_archive(date_created);
+CREATE INDEX IF NOT EXISTS idx_orders_date_created ON orders(date_created);
@@ -0,0 +1,37 @@
+-- Обратное преобразование в обычные таблицы с уникальностью order_uid
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_class WHERE relname = 'orders' AND relkind = 'p'
+               AND relnamespace = 'public'::regnamespace) THEN
+        CREATE TABLE orders_plain (LIKE orders INCLUDING DEFAULTS);
+        INSERT INTO orders_plain SELECT * FROM orders;
+        ALTER SEQUENCE orders_id_seq OWNED BY NONE;
+        DROP TABLE orders CASCADE;
+        ALTER TABLE orders_plain RENAME TO orders;
+        ALTER SEQUENCE orders_id_seq OWNED BY orders.id;
+        ALTER TABLE orders ADD PRIMARY KEY (id);
+        ALTER TABLE orders ADD UNIQUE (order_uid);
+        ALTER TABLE orders ADD UNIQUE (track_number);
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM pg_class WHERE relname = 'items' AND relkind = 'p'
+               AND relnamespace = 'public'::regnamespace) THEN
+        CREATE TABLE items_plain (LIKE items INCLUDING DEFAULTS INCLUDING CONSTRAINTS);
+        INSERT INTO items_plain SELECT * FROM items;
+        DROP TABLE items CASCADE;
+        ALTER TABLE items_plain RENAME TO items;
+        ALTER TABLE items DROP COLUMN date_created;
+        ALTER TABLE items ADD PRIMARY KEY (chrt_id);
+    END IF;
+END $$;
+
+ALTER TABLE delivery DROP CONSTRAINT IF EXISTS delivery_order_keys_fkey;
+ALTER TABLE payment DROP CONSTRAINT IF EXISTS payment_order_keys_fkey;
+ALTER TABLE status_history DROP CONSTRAINT IF EXISTS status_history_order_keys_fkey;
+DROP TABLE IF EXISTS order_keys;
+
+ALTER TABLE delivery ADD FOREIGN KEY (order_id) REFERENCES orders(order_uid) ON DELETE CASCADE;
+ALTER TABLE payment ADD FOREIGN KEY (order_id) REFERENCES orders(order_uid) ON DELETE CASCADE;
+ALTER TABLE items ADD FOREIGN KEY (order_id) REFERENCES orders(order_uid) ON DELETE CASCADE;
+ALTER TABLE status_history ADD FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_items_order_id ON items(order_id);
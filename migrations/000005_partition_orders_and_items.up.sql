@@ -0,0 +1,87 @@
+-- Секционирование orders и items по date_created (помесячно, секции создаёт сервис).
+-- Уникальный индекс секционированной таблицы обязан включать ключ секционирования,
+-- поэтому глобальная уникальность order_uid/track_number и внешние ключи
+-- переезжают в order_keys. Миграция идемпотентна: повторный запуск ничего не делает.
+
+-- Глобальный индекс заказов
+CREATE TABLE IF NOT EXISTS order_keys (
+    order_uid UUID PRIMARY KEY,
+    track_number VARCHAR(64) NOT NULL UNIQUE,
+    date_created TIMESTAMP NOT NULL
+);
+
+-- orders -> PARTITION BY RANGE (date_created)
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_class WHERE relname = 'orders' AND relkind = 'r'
+               AND relnamespace = 'public'::regnamespace) THEN
+        INSERT INTO order_keys (order_uid, track_number, date_created)
+        SELECT order_uid, track_number, date_created FROM orders
+        ON CONFLICT DO NOTHING;
+
+        CREATE TABLE orders_partitioned (LIKE orders INCLUDING DEFAULTS)
+            PARTITION BY RANGE (date_created);
+        ALTER TABLE orders_partitioned ADD PRIMARY KEY (id, date_created);
+        CREATE TABLE orders_default PARTITION OF orders_partitioned DEFAULT;
+        INSERT INTO orders_partitioned SELECT * FROM orders;
+
+        -- Последовательность id переживает удаление старой таблицы
+        ALTER SEQUENCE orders_id_seq OWNED BY NONE;
+        DROP TABLE orders CASCADE;
+        ALTER TABLE orders_partitioned RENAME TO orders;
+        ALTER SEQUENCE orders_id_seq OWNED BY orders.id;
+    END IF;
+END $$;
+
+-- items -> PARTITION BY RANGE (date_created), дата заказа денормализуется в items
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_class WHERE relname = 'items' AND relkind = 'r'
+               AND relnamespace = 'public'::regnamespace) THEN
+        ALTER TABLE items ADD COLUMN IF NOT EXISTS date_created TIMESTAMP;
+        UPDATE items i SET date_created = k.date_created
+        FROM order_keys k WHERE k.order_uid = i.order_id;
+        UPDATE items SET date_created = 'epoch' WHERE date_created IS NULL;
+        ALTER TABLE items ALTER COLUMN date_created SET NOT NULL;
+
+        CREATE TABLE items_partitioned (LIKE items INCLUDING DEFAULTS INCLUDING CONSTRAINTS)
+            PARTITION BY RANGE (date_created);
+        ALTER TABLE items_partitioned ADD PRIMARY KEY (chrt_id, date_created);
+        CREATE TABLE items_default PARTITION OF items_partitioned DEFAULT;
+        INSERT INTO items_partitioned SELECT * FROM items;
+
+        DROP TABLE items CASCADE;
+        ALTER TABLE items_partitioned RENAME TO items;
+    END IF;
+END $$;
+
+-- Внешние ключи дочерних таблиц теперь ссылаются на order_keys
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'delivery_order_keys_fkey') THEN
+        ALTER TABLE delivery DROP CONSTRAINT IF EXISTS delivery_order_id_fkey;
+        ALTER TABLE delivery ADD CONSTRAINT delivery_order_keys_fkey
+            FOREIGN KEY (order_id) REFERENCES order_keys(order_uid) ON DELETE CASCADE;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'payment_order_keys_fkey') THEN
+        ALTER TABLE payment DROP CONSTRAINT IF EXISTS payment_order_id_fkey;
+        ALTER TABLE payment ADD CONSTRAINT payment_order_keys_fkey
+            FOREIGN KEY (order_id) REFERENCES order_keys(order_uid) ON DELETE CASCADE;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'items_order_keys_fkey') THEN
+        ALTER TABLE items ADD CONSTRAINT items_order_keys_fkey
+            FOREIGN KEY (order_id) REFERENCES order_keys(order_uid) ON DELETE CASCADE;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'status_history_order_keys_fkey') THEN
+        ALTER TABLE status_history DROP CONSTRAINT IF EXISTS status_history_order_uid_fkey;
+        ALTER TABLE status_history ADD CONSTRAINT status_history_order_keys_fkey
+            FOREIGN KEY (order_uid) REFERENCES order_keys(order_uid) ON DELETE CASCADE;
+    END IF;
+END $$;
+
+-- Индексы (создаются на всех секциях)
+CREATE INDEX IF NOT EXISTS idx_orders_order_uid ON orders(order_uid, date_created);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status);
+CREATE INDEX IF NOT EXISTS idx_orders_date_created ON orders(date_created);
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_items_order_id ON items(order_id, date_created);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_items_nm_id;
+DROP INDEX IF EXISTS idx_items_rid;
+DROP INDEX IF EXISTS idx_items_search;
+DROP INDEX IF EXISTS idx_delivery_search;
@@ -0,0 +1,16 @@
+-- Поиск заказов: полнотекстовые GIN-индексы по выражениям и индексы точного поиска.
+-- Выражения tsvector должны совпадать с repository/search.go, иначе индекс не используется.
+-- Генерируемые столбцы не используются: items секционирована, а индекс по выражению
+-- на секционированной таблице автоматически создаётся и в новых секциях.
+
+CREATE INDEX IF NOT EXISTS idx_delivery_search ON delivery USING GIN (
+    to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(city, '') || ' ' ||
+                          coalesce(phone, '') || ' ' || coalesce(email, ''))
+);
+
+CREATE INDEX IF NOT EXISTS idx_items_search ON items USING GIN (
+    to_tsvector('simple', name || ' ' || brand)
+);
+
+CREATE INDEX IF NOT EXISTS idx_items_rid ON items(rid);
+CREATE INDEX IF NOT EXISTS idx_items_nm_id ON items(nm_id);
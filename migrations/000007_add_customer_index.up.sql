@@ -0,0 +1,2 @@
+-- Поиск заказов клиента: GET /customers/{id}/orders
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders(customer_id, date_created DESC);
@@ -0,0 +1,25 @@
+-- Дневные итоги продаж: день × служба доставки × платёжный провайдер × валюта.
+-- Поддерживаются репозиторием в транзакциях записи; при первом создании
+-- заполняются по текущим данным.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_class WHERE relname = 'order_rollups_daily'
+                   AND relnamespace = 'public'::regnamespace) THEN
+        CREATE TABLE order_rollups_daily (
+            day DATE NOT NULL,
+            delivery_service VARCHAR(64) NOT NULL,
+            provider VARCHAR(32) NOT NULL,
+            currency VARCHAR(10) NOT NULL,
+            orders BIGINT NOT NULL DEFAULT 0,
+            revenue BIGINT NOT NULL DEFAULT 0,
+            PRIMARY KEY (day, delivery_service, provider, currency)
+        );
+
+        INSERT INTO order_rollups_daily (day, delivery_service, provider, currency, orders, revenue)
+        SELECT o.date_created::date, o.delivery_service, p.provider, p.currency, COUNT(*), SUM(p.amount)
+        FROM orders o
+        JOIN payment p ON p.order_id = o.order_uid
+        WHERE o.deleted_at IS NULL
+        GROUP BY 1, 2, 3, 4;
+    END IF;
+END $$;
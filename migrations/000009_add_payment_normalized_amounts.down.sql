@@ -0,0 +1,6 @@
+ALTER TABLE payment DROP COLUMN IF EXISTS custom_fee_normalized;
+ALTER TABLE payment DROP COLUMN IF EXISTS goods_total_normalized;
+ALTER TABLE payment DROP COLUMN IF EXISTS delivery_cost_normalized;
+ALTER TABLE payment DROP COLUMN IF EXISTS amount_normalized;
+ALTER TABLE payment DROP COLUMN IF EXISTS rate_date;
+ALTER TABLE payment DROP COLUMN IF EXISTS normalized_currency;
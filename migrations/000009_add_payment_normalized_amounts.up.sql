@@ -0,0 +1,7 @@
+-- Суммы оплаты в валюте отчётности (в минимальных единицах) и дата версии курсов
+ALTER TABLE payment ADD COLUMN IF NOT EXISTS normalized_currency VARCHAR(3) NOT NULL DEFAULT '';
+ALTER TABLE payment ADD COLUMN IF NOT EXISTS rate_date DATE;
+ALTER TABLE payment ADD COLUMN IF NOT EXISTS amount_normalized BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE payment ADD COLUMN IF NOT EXISTS delivery_cost_normalized BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE payment ADD COLUMN IF NOT EXISTS goods_total_normalized BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE payment ADD COLUMN IF NOT EXISTS custom_fee_normalized BIGINT NOT NULL DEFAULT 0;
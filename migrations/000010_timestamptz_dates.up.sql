@@ -0,0 +1,83 @@
+-- date_created и payment_dt хранятся как TIMESTAMPTZ. Прежние значения TIMESTAMP
+-- записывались в UTC и так и интерпретируются. Миграция идемпотентна:
+-- уже преобразованные столбцы пропускаются.
+DO $$
+BEGIN
+    IF (SELECT data_type FROM information_schema.columns WHERE table_schema = 'public'
+        AND table_name = 'order_keys' AND column_name = 'date_created') = 'timestamp without time zone' THEN
+        ALTER TABLE order_keys ALTER COLUMN date_created TYPE TIMESTAMPTZ USING date_created AT TIME ZONE 'UTC';
+    END IF;
+    IF (SELECT data_type FROM information_schema.columns WHERE table_schema = 'public'
+        AND table_name = 'payment' AND column_name = 'payment_dt') = 'timestamp without time zone' THEN
+        ALTER TABLE payment ALTER COLUMN payment_dt TYPE TIMESTAMPTZ USING payment_dt AT TIME ZONE 'UTC';
+    END IF;
+    IF (SELECT data_type FROM information_schema.columns WHERE table_schema = 'public'
+        AND table_name = 'orders_archive' AND column_name = 'date_created') = 'timestamp without time zone' THEN
+        ALTER TABLE orders_archive ALTER COLUMN date_created TYPE TIMESTAMPTZ USING date_created AT TIME ZONE 'UTC';
+    END IF;
+END $$;
+
+-- Тип ключа секционирования не меняется через ALTER, поэтому orders и items
+-- пересоздаются с теми же столбцами, ограничениями и индексами. Все строки
+-- попадают в DEFAULT-секцию, помесячные секции заново создаёт сервис при старте.
+DO $$
+DECLARE
+    tbl TEXT;
+    column_defs TEXT;
+    statements TEXT[];
+    stmt TEXT;
+BEGIN
+    -- INSERT ... SELECT * приводит TIMESTAMP и TIMESTAMPTZ друг к другу в зоне сеанса
+    PERFORM set_config('TimeZone', 'UTC', true);
+
+    FOREACH tbl IN ARRAY ARRAY['orders', 'items'] LOOP
+        IF (SELECT format_type(atttypid, atttypmod) FROM pg_attribute
+            WHERE attrelid = to_regclass('public.' || tbl) AND attname = 'date_created')
+            IS DISTINCT FROM 'timestamp without time zone' THEN
+            CONTINUE;
+        END IF;
+
+        SELECT string_agg(format('%I %s%s%s', a.attname,
+                   CASE WHEN a.attname = 'date_created' THEN 'timestamp with time zone'
+                        ELSE format_type(a.atttypid, a.atttypmod) END,
+                   CASE WHEN d.adbin IS NOT NULL THEN ' DEFAULT ' || pg_get_expr(d.adbin, d.adrelid) ELSE '' END,
+                   CASE WHEN a.attnotnull THEN ' NOT NULL' ELSE '' END), ', ' ORDER BY a.attnum)
+        INTO column_defs
+        FROM pg_attribute a
+        LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+        WHERE a.attrelid = tbl::regclass AND a.attnum > 0 AND NOT a.attisdropped;
+
+        -- Ограничения (первичный ключ первым) и остальные индексы таблицы
+        SELECT coalesce(array_agg(format('ALTER TABLE %I ADD CONSTRAINT %I %s', tbl, conname, pg_get_constraintdef(oid))
+                   ORDER BY contype = 'p' DESC, conname), '{}')
+        INTO statements
+        FROM pg_constraint
+        WHERE conrelid = tbl::regclass AND contype IN ('p', 'u', 'c', 'f');
+
+        SELECT statements || coalesce(array_agg(replace(pg_get_indexdef(i.indexrelid), ' ON ONLY ', ' ON ')), '{}')
+        INTO statements
+        FROM pg_index i
+        WHERE i.indrelid = tbl::regclass
+          AND NOT EXISTS (SELECT 1 FROM pg_constraint c WHERE c.conrelid = i.indrelid AND c.conindid = i.indexrelid);
+
+        EXECUTE format('ALTER TABLE %I RENAME TO %I', tbl, tbl || '_naive');
+        EXECUTE format('ALTER TABLE IF EXISTS %I RENAME TO %I', tbl || '_default', tbl || '_default_naive');
+
+        EXECUTE format('CREATE TABLE %I (%s) PARTITION BY RANGE (date_created)', tbl, column_defs);
+        EXECUTE format('CREATE TABLE %I PARTITION OF %I DEFAULT', tbl || '_default', tbl);
+        EXECUTE format('INSERT INTO %I SELECT * FROM %I', tbl, tbl || '_naive');
+
+        -- Последовательность id переживает удаление старой таблицы
+        IF tbl = 'orders' THEN
+            ALTER SEQUENCE orders_id_seq OWNED BY NONE;
+        END IF;
+        EXECUTE format('DROP TABLE %I CASCADE', tbl || '_naive');
+        IF tbl = 'orders' THEN
+            ALTER SEQUENCE orders_id_seq OWNED BY orders.id;
+        END IF;
+
+        FOREACH stmt IN ARRAY statements LOOP
+            EXECUTE stmt;
+        END LOOP;
+    END LOOP;
+END $$;
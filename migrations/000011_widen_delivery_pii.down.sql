@@ -0,0 +1,7 @@
+-- Возврат прежних ограничений длины; зашифрованные значения перед этим
+-- нужно расшифровать, иначе они не поместятся
+ALTER TABLE delivery
+    ALTER COLUMN name TYPE VARCHAR(128),
+    ALTER COLUMN phone TYPE VARCHAR(15),
+    ALTER COLUMN address TYPE VARCHAR(64),
+    ALTER COLUMN email TYPE VARCHAR(128);
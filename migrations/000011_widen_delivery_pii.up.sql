@@ -0,0 +1,15 @@
+-- Персональные данные доставки могут храниться зашифрованными (PII_ENCRYPTION_KEY):
+-- шифротекст длиннее исходного значения, поэтому столбцы становятся TEXT.
+-- Длина открытых значений по-прежнему проверяется при валидации заказа.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_schema = 'public'
+               AND table_name = 'delivery' AND column_name IN ('name', 'phone', 'address', 'email')
+               AND data_type <> 'text') THEN
+        ALTER TABLE delivery
+            ALTER COLUMN name TYPE TEXT,
+            ALTER COLUMN phone TYPE TEXT,
+            ALTER COLUMN address TYPE TEXT,
+            ALTER COLUMN email TYPE TEXT;
+    END IF;
+END $$;
@@ -0,0 +1,3 @@
+ALTER TABLE order_rollups_daily
+    DROP COLUMN IF EXISTS revenue_normalized,
+    DROP COLUMN IF EXISTS unconverted;
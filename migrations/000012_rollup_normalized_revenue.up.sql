@@ -0,0 +1,13 @@
+-- Выручка в валюте отчётности (минимальные единицы, по payment.amount_normalized)
+-- и число заказов без пересчитанной суммы. Существующие строки считаются
+-- непересчитанными, пока их не заполнит make rollup.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_schema = 'public'
+                   AND table_name = 'order_rollups_daily' AND column_name = 'revenue_normalized') THEN
+        ALTER TABLE order_rollups_daily
+            ADD COLUMN revenue_normalized BIGINT NOT NULL DEFAULT 0,
+            ADD COLUMN unconverted BIGINT NOT NULL DEFAULT 0;
+        UPDATE order_rollups_daily SET unconverted = orders;
+    END IF;
+END $$;
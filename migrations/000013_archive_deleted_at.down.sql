@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_orders_archive_deleted_at;
+ALTER TABLE orders_archive DROP COLUMN IF EXISTS deleted_at;
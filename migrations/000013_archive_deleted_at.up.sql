@@ -0,0 +1,4 @@
+-- Архивируются и отменённые (мягко удалённые) заказы; отметка удаления
+-- переносится в архив, чтобы такие заказы не находились и там
+ALTER TABLE orders_archive ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_orders_archive_deleted_at ON orders_archive(deleted_at);
@@ -0,0 +1,2 @@
+ALTER TABLE payment DROP COLUMN IF EXISTS payment_dt_millis;
+ALTER TABLE orders DROP COLUMN IF EXISTS date_created_offset;
@@ -0,0 +1,9 @@
+-- TIMESTAMPTZ хранит только момент: исходное смещение date_created (секунды
+-- от UTC) и единица payment_dt (секунды или миллисекунды) хранятся отдельно,
+-- чтобы заказ отдавался в API таким же, каким пришёл
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS date_created_offset INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE payment ADD COLUMN IF NOT EXISTS payment_dt_millis BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- payment_dt в JSON — не точнее миллисекунд
+UPDATE payment SET payment_dt = date_trunc('milliseconds', payment_dt)
+WHERE payment_dt <> date_trunc('milliseconds', payment_dt);
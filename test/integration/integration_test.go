@@ -10,9 +10,11 @@ import (
 	"testing"
 	"time"
 
+	v1 "github.com/Sphirium/wb-tech-demo-lo/internal/api/v1"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/cache"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/handler"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/money"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
 	"github.com/go-chi/chi/v5"
@@ -32,29 +34,21 @@ func setupTestServer() (*http.ServeMux, *gorm.DB, func()) {
 	}
 
 	// Очищаем и мигрируем
-	db.Migrator().DropTable(&models.Order{}, &models.Item{})
-	db.AutoMigrate(&models.Order{}, &models.Item{})
+	db.Migrator().DropTable(&models.Order{}, &models.Item{}, &models.OrderKey{})
+	db.AutoMigrate(&models.OrderKey{}, &models.Order{}, &models.Item{})
 
-	repo := repository.NewOrderRepository(db)
-	cache := cache.NewOrderCache("localhost:6379", "")
+	repo := repository.NewOrderRepository(db, "RUB")
+	cache := cache.NewOrderCache("localhost:6379", "", nil)
 	orderService := service.NewOrderService(repo, cache)
 
 	// Создаём роутер с обработчиками
 	r := chi.NewRouter()
 	orderHandler := handler.NewOrderHandler(orderService)
 
-	// Эндпоинт для приёма заказа (аналог Kafka)
-	r.Post("/create", func(w http.ResponseWriter, r *http.Request) {
-		body, _ := io.ReadAll(r.Body)
-		defer r.Body.Close()
-
-		if err := orderService.SaveOrder(body); err != nil {
-			http.Error(w, "Invalid order data", http.StatusBadRequest)
-			return
-		}
-		w.WriteHeader(http.StatusCreated)
-		_, _ = w.Write([]byte(`{"status": "created"}`))
-	})
+	// Эндпоинты приёма заказов
+	ingestHandler := handler.NewIngestHandler(orderService, nil)
+	r.Post("/orders", ingestHandler.CreateOrder)
+	r.Post("/orders:batch", ingestHandler.CreateOrdersBatch)
 
 	// Эндпоинт для получения заказа
 	r.Get("/order/{order_uid}", orderHandler.GetOrder)
@@ -67,7 +61,7 @@ func setupTestServer() (*http.ServeMux, *gorm.DB, func()) {
 	teardown := func() {
 		cache.Close()
 		// Очистка таблиц
-		db.Migrator().DropTable(&models.Order{}, &models.Item{})
+		db.Migrator().DropTable(&models.Order{}, &models.Item{}, &models.OrderKey{})
 	}
 
 	return mux, db, teardown
@@ -106,7 +100,7 @@ func TestCreateAndRetrieveOrder(t *testing.T) {
 			Amount:       1000,
 			DeliveryCost: 200,
 			GoodsTotal:   800,
-			PaymentDt:    1637907727,
+			PaymentDt:    models.NewUnixTime(time.Unix(1637907727, 0)),
 			Bank:         "alpha",
 		},
 		Items: []models.Item{
@@ -128,7 +122,7 @@ func TestCreateAndRetrieveOrder(t *testing.T) {
 		Locale:          "en",
 		CustomerID:      "cust_001",
 		DeliveryService: "meest",
-		DateCreated:     "2023-01-01T10:00:00Z",
+		DateCreated:     models.NewTimestamp(time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)),
 		Shardkey:        "1",
 		SMID:            1,
 		OofShard:        "1",
@@ -136,8 +130,8 @@ func TestCreateAndRetrieveOrder(t *testing.T) {
 
 	jsonData, _ := json.Marshal(testOrder)
 
-	// Этап 1: Отправляем заказ через /create
-	resp, err := http.Post(server.URL+"/create", "application/json", bytes.NewBuffer(jsonData))
+	// Этап 1: Отправляем заказ через POST /orders
+	resp, err := http.Post(server.URL+"/orders", "application/json", bytes.NewBuffer(jsonData))
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusCreated, resp.StatusCode)
 
@@ -148,7 +142,7 @@ func TestCreateAndRetrieveOrder(t *testing.T) {
 
 	// Читаем тело ответа
 	body, _ := io.ReadAll(resp.Body)
-	var retrievedOrder models.Order
+	var retrievedOrder v1.Order
 	err = json.Unmarshal(body, &retrievedOrder)
 	assert.NoError(t, err)
 
@@ -188,7 +182,7 @@ func TestInvalidJSON(t *testing.T) {
 	server := httptest.NewServer(mux)
 	defer server.Close()
 
-	resp, err := http.Post(server.URL+"/create", "application/json", bytes.NewBuffer([]byte("invalid json")))
+	resp, err := http.Post(server.URL+"/orders", "application/json", bytes.NewBuffer([]byte("invalid json")))
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
@@ -201,28 +195,10 @@ func TestEmptyOrderUIDGeneratesUUID(t *testing.T) {
 	server := httptest.NewServer(mux)
 	defer server.Close()
 
-	testOrder := map[string]interface{}{
-		"order_uid":    "", // ✅ пустой
-		"track_number": "TRACK999",
-		"delivery": map[string]string{
-			"name": "Test",
-		},
-		"payment": map[string]interface{}{
-			"amount": 500,
-		},
-		"items": []map[string]interface{}{
-			{
-				"chrt_id":     1,
-				"name":        "Dummy",
-				"price":       500,
-				"total_price": 500,
-			},
-		},
-		"locale": "en",
-	}
+	testOrder := validOrderPayload("", "TRACK999")
 
 	jsonData, _ := json.Marshal(testOrder)
-	resp, err := http.Post(server.URL+"/create", "application/json", bytes.NewBuffer(jsonData))
+	resp, err := http.Post(server.URL+"/orders", "application/json", bytes.NewBuffer(jsonData))
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusCreated, resp.StatusCode)
 
@@ -234,3 +210,195 @@ func TestEmptyOrderUIDGeneratesUUID(t *testing.T) {
 	assert.NotEqual(t, uuid.Nil.String(), order.OrderUID)
 	fmt.Printf("Generated OrderUID: %s\n", order.OrderUID)
 }
+
+// Тест: невалидный заказ — 422 с ошибками по полям
+func TestCreateOrderValidationErrors(t *testing.T) {
+	mux, _, teardown := setupTestServer()
+	defer teardown()
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	testOrder := validOrderPayload("", "TRACK422")
+	delete(testOrder, "delivery")
+	testOrder["items"] = []map[string]interface{}{}
+
+	jsonData, _ := json.Marshal(testOrder)
+	resp, err := http.Post(server.URL+"/orders", "application/json", bytes.NewBuffer(jsonData))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	var body struct {
+		Fields map[string]string `json:"fields"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Contains(t, body.Fields, "delivery")
+	assert.Contains(t, body.Fields, "items")
+}
+
+// Тест: повторная отправка заказа — 409, пакет из NDJSON — 207
+func TestCreateOrderDuplicateAndBatch(t *testing.T) {
+	mux, _, teardown := setupTestServer()
+	defer teardown()
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	uid := uuid.New().String()
+	jsonData, _ := json.Marshal(validOrderPayload(uid, "TRACKDUP"))
+
+	resp, err := http.Post(server.URL+"/orders", "application/json", bytes.NewBuffer(jsonData))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = http.Post(server.URL+"/orders", "application/json", bytes.NewBuffer(jsonData))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	second, _ := json.Marshal(validOrderPayload("", "TRACKNEW"))
+	ndjson := append(append(jsonData, '\n'), second...)
+	resp, err = http.Post(server.URL+"/orders:batch", "application/x-ndjson", bytes.NewBuffer(ndjson))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+}
+
+// Тест: transaction оплаты отличается от order_uid и сохраняется как есть
+func TestCreateOrderWithDistinctTransaction(t *testing.T) {
+	_, db, teardown := setupTestServer()
+	defer teardown()
+
+	repo := repository.NewOrderRepository(db, "RUB")
+	orderUID := uuid.New().String()
+	transaction := uuid.New().String()
+
+	order := repositoryOrder(orderUID, "TRACKTX")
+	order.Payment.Transaction = transaction
+	assert.NoError(t, repo.Create(order))
+	assert.Equal(t, transaction, order.Payment.Transaction)
+
+	found, err := repo.FindByTransaction(transaction, repository.IncludeAll)
+	assert.NoError(t, err)
+	if assert.NotNil(t, found) && assert.NotNil(t, found.Payment) {
+		assert.Equal(t, orderUID, found.OrderUID)
+		assert.Equal(t, transaction, found.Payment.Transaction)
+	}
+
+	byUID, err := repo.FindByOrderUID(orderUID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, byUID) && assert.NotNil(t, byUID.Payment) {
+		assert.Equal(t, transaction, byUID.Payment.Transaction)
+	}
+}
+
+// Тест: сумма, пересчитанная не в валюту отчётности, попадает в unconverted, как при пересчёте
+func TestRollupCountsOnlyReportingCurrency(t *testing.T) {
+	_, db, teardown := setupTestServer()
+	defer teardown()
+
+	repo := repository.NewOrderRepository(db, "RUB")
+	delivery := "rollup-" + uuid.New().String()[:8]
+	for i, currency := range []string{"RUB", "USD"} {
+		order := repositoryOrder(uuid.New().String(), fmt.Sprintf("TRACKRU%d", i))
+		order.DeliveryService = delivery
+		order.Payment.Normalized = &models.NormalizedPayment{
+			RateDate:     "2021-11-26",
+			Amount:       money.Money{Amount: 100, Currency: currency},
+			DeliveryCost: money.Money{Currency: currency},
+			GoodsTotal:   money.Money{Currency: currency},
+			CustomFee:    money.Money{Currency: currency},
+		}
+		assert.NoError(t, repo.Create(order))
+	}
+
+	var rollup struct {
+		Orders            int64
+		RevenueNormalized int64
+		Unconverted       int64
+	}
+	err := db.Raw("SELECT orders, revenue_normalized, unconverted FROM order_rollups_daily WHERE delivery_service = ?", delivery).
+		Scan(&rollup).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), rollup.Orders)
+	assert.Equal(t, int64(100), rollup.RevenueNormalized)
+	assert.Equal(t, int64(1), rollup.Unconverted)
+}
+
+// repositoryOrder возвращает валидный заказ для записи через репозиторий
+func repositoryOrder(orderUID, track string) *models.Order {
+	return &models.Order{
+		OrderUID:    orderUID,
+		TrackNumber: track,
+		Entry:       "WBIL",
+		Delivery: &models.Delivery{
+			OrderID: orderUID,
+			Name:    "Test",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+		},
+		Payment: &models.Payment{
+			Transaction: orderUID,
+			OrderID:     orderUID,
+			Currency:    "USD",
+			Provider:    "wbpay",
+			Amount:      500,
+			PaymentDt:   models.NewUnixTime(time.Unix(1637907727, 0)),
+			Bank:        "alpha",
+		},
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		DateCreated:     models.NewTimestamp(time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)),
+		Shardkey:        "9",
+		SMID:            99,
+		OofShard:        "1",
+		Status:          models.StatusCreated,
+	}
+}
+
+// validOrderPayload возвращает минимальный заказ, проходящий валидацию
+func validOrderPayload(orderUID, track string) map[string]interface{} {
+	return map[string]interface{}{
+		"order_uid":    orderUID,
+		"track_number": track,
+		"entry":        "WBIL",
+		"delivery": map[string]string{
+			"name":    "Test",
+			"phone":   "+9720000000",
+			"zip":     "2639809",
+			"city":    "Kiryat Mozkin",
+			"address": "Ploshad Mira 15",
+			"region":  "Kraiot",
+			"email":   "test@gmail.com",
+		},
+		"payment": map[string]interface{}{
+			"currency":   "USD",
+			"provider":   "wbpay",
+			"amount":     500,
+			"payment_dt": 1637907727,
+			"bank":       "alpha",
+		},
+		"items": []map[string]interface{}{
+			{
+				"chrt_id":      time.Now().UnixNano() % 1e9,
+				"track_number": track,
+				"rid":          "rid-" + track,
+				"name":         "Dummy",
+				"size":         "0",
+				"brand":        "Brand",
+				"nm_id":        1,
+				"price":        500,
+				"total_price":  500,
+			},
+		},
+		"locale":           "en",
+		"customer_id":      "test",
+		"delivery_service": "meest",
+		"shardkey":         "9",
+		"sm_id":            99,
+		"date_created":     "2021-11-26T06:22:19Z",
+		"oof_shard":        "1",
+	}
+}
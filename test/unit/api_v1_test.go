@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	v1 "github.com/Sphirium/wb-tech-demo-lo/internal/api/v1"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadModelOrder(t *testing.T) *models.Order {
+	data, err := os.ReadFile("../../docs/model.json")
+	require.NoError(t, err)
+
+	var order models.Order
+	require.NoError(t, json.Unmarshal(data, &order))
+	return &order
+}
+
+func TestV1Order_HidesStorageFields(t *testing.T) {
+	order := loadModelOrder(t)
+	order.ID = 42
+	order.CreatedAt = time.Now()
+	order.UpdatedAt = time.Now()
+	order.Status = models.StatusPaid
+
+	data, err := json.Marshal(v1.FromOrder(order))
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	for _, name := range []string{"id", "ID", "CreatedAt", "UpdatedAt", "created_at", "updated_at"} {
+		assert.NotContains(t, fields, name)
+	}
+	assert.Equal(t, "paid", fields["status"])
+	assert.NotContains(t, fields["payment"], "OrderID")
+}
+
+func TestV1Order_MatchesUpstreamFormat(t *testing.T) {
+	source, err := os.ReadFile("../../docs/model.json")
+	require.NoError(t, err)
+
+	var order models.Order
+	require.NoError(t, json.Unmarshal(source, &order))
+	encoded, err := json.Marshal(v1.FromOrder(&order))
+	require.NoError(t, err)
+
+	var expected, actual map[string]any
+	require.NoError(t, json.Unmarshal(source, &expected))
+	require.NoError(t, json.Unmarshal(encoded, &actual))
+	delete(actual, "status")
+	assert.Equal(t, expected, actual)
+}
+
+func TestV1Order_ModelRoundTrip(t *testing.T) {
+	order := loadModelOrder(t)
+	dto := v1.FromOrder(order)
+
+	data, err := json.Marshal(dto)
+	require.NoError(t, err)
+	var decoded v1.Order
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	restored := decoded.Model()
+	assert.Equal(t, dto, v1.FromOrder(restored))
+	assert.Equal(t, order.OrderUID, restored.Payment.OrderID)
+	assert.Equal(t, order.OrderUID, restored.Items[0].OrderID)
+	assert.Equal(t, order.DateCreated, restored.Items[0].DateCreated)
+}
+
+func TestV1Orders_EmptyListIsArray(t *testing.T) {
+	data, err := json.Marshal(v1.FromOrders(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(data))
+}
@@ -0,0 +1,179 @@
+package unit
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/auth"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/handler"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func b64url(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// signJWT подписывает claims ключом ES256 или RS256
+func signJWT(t *testing.T, key crypto.Signer, kid string, claims map[string]any) string {
+	t.Helper()
+	alg := "RS256"
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		alg = "ES256"
+	}
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64url(header) + "." + b64url(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		require.NoError(t, err)
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case *rsa.PrivateKey:
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	}
+	return signed + "." + b64url(sig)
+}
+
+func testJWKS(t *testing.T, ec *ecdsa.PublicKey, rs *rsa.PublicKey) *auth.JWKS {
+	t.Helper()
+	set := map[string]any{"keys": []map[string]string{
+		{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64url(ec.X.FillBytes(make([]byte, 32))), "y": b64url(ec.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "RSA", "kid": "rsa1", "alg": "RS256", "n": b64url(rs.N.Bytes()), "e": b64url(big.NewInt(int64(rs.E)).Bytes())},
+	}}
+	data, _ := json.Marshal(set)
+	jwks, err := auth.ParseJWKS(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	return jwks
+}
+
+func TestJWTVerifier(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	verifier := auth.NewJWTVerifier(testJWKS(t, &ecKey.PublicKey, &rsaKey.PublicKey), "https://idp.example", "orders").
+		WithClock(func() time.Time { return now })
+	claims := func(extra map[string]any) map[string]any {
+		c := map[string]any{
+			"sub": "svc-reports", "iss": "https://idp.example", "aud": []string{"orders", "other"},
+			"exp": now.Add(time.Hour).Unix(), "scope": "orders:read orders:pii billing:read",
+		}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+
+	p, err := verifier.Verify(signJWT(t, ecKey, "ec1", claims(nil)))
+	require.NoError(t, err)
+	assert.Equal(t, "svc-reports", p.Subject)
+	assert.Equal(t, []auth.Scope{auth.ScopeRead, auth.ScopePII}, p.Scopes, "unknown scopes are ignored")
+	assert.Equal(t, pii.RoleSupport, p.Role(pii.RolePublic))
+
+	p, err = verifier.Verify(signJWT(t, rsaKey, "rsa1", claims(map[string]any{"scope": nil, "scp": []string{"orders:admin"}})))
+	require.NoError(t, err)
+	assert.True(t, p.Has(auth.ScopeWrite), "orders:admin implies every scope")
+
+	for name, token := range map[string]string{
+		"expired":      signJWT(t, ecKey, "ec1", claims(map[string]any{"exp": now.Add(-time.Hour).Unix()})),
+		"not yet":      signJWT(t, ecKey, "ec1", claims(map[string]any{"nbf": now.Add(time.Hour).Unix()})),
+		"no exp":       signJWT(t, ecKey, "ec1", claims(map[string]any{"exp": nil})),
+		"wrong iss":    signJWT(t, ecKey, "ec1", claims(map[string]any{"iss": "https://evil.example"})),
+		"wrong aud":    signJWT(t, ecKey, "ec1", claims(map[string]any{"aud": "billing"})),
+		"unknown kid":  signJWT(t, ecKey, "ec2", claims(nil)),
+		"key mismatch": signJWT(t, ecKey, "rsa1", claims(nil)),
+		"bad sig":      signJWT(t, ecKey, "ec1", claims(nil))[:40] + "x" + signJWT(t, ecKey, "ec1", claims(nil))[41:],
+		"alg none":     b64url([]byte(`{"alg":"none","kid":"ec1"}`)) + "." + b64url([]byte(`{"sub":"x","exp":9999999999}`)) + ".",
+	} {
+		_, err := verifier.Verify(token)
+		assert.ErrorIs(t, err, auth.ErrUnauthorized, name)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	hash := sha256.Sum256([]byte("s3cret"))
+	key, err := auth.ParseAPIKey("ci:" + hex.EncodeToString(hash[:]) + ":orders:read orders:write")
+	require.NoError(t, err)
+	assert.Equal(t, "ci", key.Name)
+	assert.Equal(t, []auth.Scope{auth.ScopeRead, auth.ScopeWrite}, key.Scopes)
+
+	for _, entry := range []string{"ci", "ci:zz:orders:read", "ci:" + hex.EncodeToString(hash[:]) + ":orders:delete"} {
+		_, err := auth.ParseAPIKey(entry)
+		assert.Error(t, err, entry)
+	}
+
+	a := auth.NewAuthenticator([]auth.APIKey{key}, nil)
+	p, err := a.Authenticate("s3cret")
+	require.NoError(t, err)
+	assert.Equal(t, "ci", p.Subject)
+	assert.False(t, p.Has(auth.ScopeAdmin))
+
+	_, err = a.Authenticate("wrong")
+	assert.ErrorIs(t, err, auth.ErrUnauthorized)
+	_, err = a.Authenticate("a.b.c")
+	assert.ErrorIs(t, err, auth.ErrUnauthorized, "JWT without a JWKS")
+}
+
+func TestAuthMiddleware_ScopesAndRoles(t *testing.T) {
+	a := auth.NewAuthenticator([]auth.APIKey{
+		auth.NewAPIKey("adm", "adm", auth.ScopeAdmin),
+		auth.NewAPIKey("sup", "sup", auth.ScopeRead, auth.ScopePII),
+		auth.NewAPIKey("writer", "writer", auth.ScopeWrite),
+	}, nil)
+
+	var role pii.Role
+	h := handler.Authenticate(a, []auth.Scope{auth.ScopeRead}, pii.RolePublic)(
+		handler.RequireScope(auth.ScopeRead)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { role = pii.RoleFrom(r.Context()) })))
+
+	for _, tc := range []struct {
+		header, value string
+		status        int
+		role          pii.Role
+	}{
+		{"", "", http.StatusOK, pii.RolePublic},
+		{"Authorization", "Bearer adm", http.StatusOK, pii.RoleAdmin},
+		{"X-API-Key", "sup", http.StatusOK, pii.RoleSupport},
+		{"Authorization", "Bearer writer", http.StatusForbidden, ""},
+		{"Authorization", "Bearer wrong", http.StatusUnauthorized, ""},
+	} {
+		role = ""
+		req := httptest.NewRequest(http.MethodGet, "/v1/order/x", nil)
+		if tc.header != "" {
+			req.Header.Set(tc.header, tc.value)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, tc.status, rec.Code, tc.value)
+		assert.Equal(t, tc.role, role, tc.value)
+	}
+
+	// Без анонимных прав запрос без учётных данных получает 401
+	closed := handler.Authenticate(a, nil, pii.RolePublic)(handler.RequireScope(auth.ScopeRead)(http.NotFoundHandler()))
+	rec := httptest.NewRecorder()
+	closed.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/order/x", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+}
@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/breaker"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestBreaker_OpensAfterFailuresAndRecovers(t *testing.T) {
+	b := breaker.New(2, 20*time.Millisecond, repository.IsInfrastructureError)
+	dbDown := connRefused()
+
+	assert.ErrorIs(t, b.Do(func() error { return dbDown }), dbDown)
+	assert.ErrorIs(t, b.Do(func() error { return dbDown }), dbDown)
+	assert.Equal(t, breaker.Open, b.State())
+
+	called := false
+	err := b.Do(func() error { called = true; return nil })
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	assert.False(t, called, "open breaker must not call the dependency")
+
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, b.Do(func() error { return nil }))
+	assert.Equal(t, breaker.Closed, b.State())
+}
+
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	b := breaker.New(1, 10*time.Millisecond, repository.IsInfrastructureError)
+	dbDown := context.DeadlineExceeded
+
+	_ = b.Do(func() error { return dbDown })
+	time.Sleep(20 * time.Millisecond)
+	_ = b.Do(func() error { return dbDown })
+	assert.Equal(t, breaker.Open, b.State())
+}
+
+func TestBreaker_IgnoresBusinessErrors(t *testing.T) {
+	b := breaker.New(1, time.Minute, repository.IsInfrastructureError)
+
+	_ = b.Do(func() error { return gorm.ErrRecordNotFound })
+	_ = b.Do(func() error { return repository.ErrDuplicate })
+	_ = b.Do(func() error { return fmt.Errorf("delivery.phone: %w", pii.ErrNoKey) })
+	_ = b.Do(func() error { return errors.New("cipher: message authentication failed") })
+	_ = b.Do(func() error { return &pgconn.PgError{Code: "23514"} })
+	assert.Equal(t, breaker.Closed, b.State())
+}
+
+func TestIsInfrastructureError(t *testing.T) {
+	assert.True(t, repository.IsInfrastructureError(connRefused()))
+	assert.True(t, repository.IsInfrastructureError(fmt.Errorf("query: %w", context.DeadlineExceeded)))
+	assert.True(t, repository.IsInfrastructureError(&pgconn.PgError{Code: "57P01"}))
+	assert.True(t, repository.IsInfrastructureError(&pgconn.PgError{Code: "40001"}))
+	assert.False(t, repository.IsInfrastructureError(context.Canceled), "the client went away, the DB is fine")
+	assert.False(t, repository.IsInfrastructureError(errors.New("unexpected")))
+}
+
+// connRefused — ошибка подключения к недоступному Postgres
+func connRefused() error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+}
+
+func TestBreakerOrderRepository_RejectsWhenOpen(t *testing.T) {
+	inner := new(MockRepo)
+	inner.On("FindByOrderUID", "u1").Return(nil, &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}).Once()
+
+	repo := repository.NewBreakerOrderRepository(inner, breaker.New(1, time.Minute, repository.IsInfrastructureError))
+
+	_, err := repo.FindByOrderUID("u1")
+	assert.Error(t, err)
+	_, err = repo.FindByOrderUID("u1")
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	inner.AssertNumberOfCalls(t, "FindByOrderUID", 1)
+	inner.AssertExpectations(t)
+}
@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "github.com/Sphirium/wb-tech-demo-lo/internal/api/v1"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/handler"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestV1Version_ChangesWithContent(t *testing.T) {
+	order := loadModelOrder(t)
+	version := v1.Version(order)
+	assert.NotEmpty(t, version)
+	assert.Equal(t, version, v1.Version(loadModelOrder(t)), "version is deterministic")
+
+	order.Status = models.StatusPaid
+	assert.NotEqual(t, version, v1.Version(order))
+
+	// Версия из кеша не пересчитывается
+	order.Version = "cached"
+	assert.Equal(t, "cached", v1.Version(order))
+}
+
+func TestGetOrder_ConditionalRequests(t *testing.T) {
+	order := loadModelOrder(t)
+	order.UpdatedAt = time.Date(2024, 6, 1, 12, 30, 15, 0, time.UTC)
+
+	mockCache := new(MockCache)
+	mockCache.On("Get", order.OrderUID).Return(order, nil)
+	serv := service.NewOrderService(new(MockRepo), mockCache)
+
+	policies, err := handler.ParseCacheControl("read=private, no-cache; search=no-store")
+	require.NoError(t, err)
+	r := chi.NewRouter()
+	r.Use(handler.Compress(5))
+	r.With(handler.CacheControl(policies, "read")).Get("/v1/order/{order_uid}", handler.NewOrderHandler(serv).GetOrder)
+
+	get := func(role pii.Role, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/order/"+order.OrderUID, nil)
+		req = req.WithContext(pii.WithRole(req.Context(), role))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get(pii.RolePublic, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	assert.Equal(t, `"`+v1.Version(order)+`.public"`, etag)
+	assert.Contains(t, rec.Header().Values("Vary"), "Accept-Encoding")
+	assert.Equal(t, "Sat, 01 Jun 2024 12:30:15 GMT", rec.Header().Get("Last-Modified"))
+	assert.Equal(t, "private, no-cache", rec.Header().Get("Cache-Control"))
+	assert.Contains(t, rec.Body.String(), `"order_uid"`)
+
+	rec = get(pii.RolePublic, map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, etag, rec.Header().Get("ETag"))
+
+	rec = get(pii.RolePublic, map[string]string{"If-None-Match": `"stale.public", ` + etag})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	// Сжатое тело — другие байты и свой сильный ETag
+	rec = get(pii.RolePublic, map[string]string{"Accept-Encoding": "gzip"})
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	gzipETag := rec.Header().Get("ETag")
+	assert.Equal(t, `"`+v1.Version(order)+`.public-gzip"`, gzipETag)
+	rec = get(pii.RolePublic, map[string]string{"Accept-Encoding": "gzip", "If-None-Match": gzipETag})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, gzipETag, rec.Header().Get("ETag"))
+	rec = get(pii.RolePublic, map[string]string{"Accept-Encoding": "br", "If-None-Match": gzipETag})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "br", rec.Header().Get("Content-Encoding"))
+	rec = get(pii.RolePublic, map[string]string{"If-None-Match": gzipETag})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Range не поддерживается: тело целиком
+	rec = get(pii.RolePublic, map[string]string{"Range": "bytes=0-9", "If-Range": etag})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Content-Range"))
+	assert.Contains(t, rec.Body.String(), `"order_uid"`)
+
+	// Другая роль — другое представление и другой ETag
+	rec = get(pii.RoleAdmin, map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+
+	rec = get(pii.RolePublic, map[string]string{"If-Modified-Since": "Sat, 01 Jun 2024 12:30:15 GMT"})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	rec = get(pii.RolePublic, map[string]string{"If-Modified-Since": "Sat, 01 Jun 2024 12:00:00 GMT"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// If-None-Match важнее If-Modified-Since
+	rec = get(pii.RolePublic, map[string]string{"If-None-Match": `"stale.public"`, "If-Modified-Since": "Sat, 01 Jun 2024 12:30:15 GMT"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	_, err = handler.ParseCacheControl("read")
+	assert.Error(t, err)
+}
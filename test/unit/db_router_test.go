@@ -0,0 +1,46 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// openLazy создаёт *gorm.DB без подключения к серверу
+func openLazy(t *testing.T, dsn string) *gorm.DB {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{DisableAutomaticPing: true})
+	require.NoError(t, err)
+	return db
+}
+
+func TestDBRouter_ReadsFromReplicasAndSticksAfterWrite(t *testing.T) {
+	primary := openLazy(t, "host=primary")
+	r1 := openLazy(t, "host=replica1")
+	r2 := openLazy(t, "host=replica2")
+
+	router := repository.NewDBRouter(primary, []*gorm.DB{r1, r2}, repository.RouterOptions{
+		StickyWindow: time.Minute,
+	})
+
+	first, second := router.Reader("u1"), router.Reader("u1")
+	assert.NotSame(t, primary, first)
+	assert.NotSame(t, primary, second)
+	assert.NotSame(t, first, second, "reads are balanced across replicas")
+
+	router.MarkWritten("u1", "track:T1")
+	assert.Same(t, primary, router.Reader("u1"))
+	assert.Same(t, primary, router.Reader("track:T1"))
+	assert.NotSame(t, primary, router.Reader("u2"))
+}
+
+func TestDBRouter_WithoutReplicasUsesPrimary(t *testing.T) {
+	primary := openLazy(t, "host=primary")
+	router := repository.NewDBRouter(primary, nil, repository.RouterOptions{})
+
+	assert.Same(t, primary, router.Reader("u1"))
+}
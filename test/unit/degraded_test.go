@@ -0,0 +1,149 @@
+package unit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/breaker"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/consumer"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/handler"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/health"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestGate_RetriesMessageUntilStorageRecovers(t *testing.T) {
+	gate := consumer.NewGate(5 * time.Millisecond)
+	defer gate.Close()
+
+	var calls atomic.Int32
+	handle := gate.Wrap(func(data []byte) error {
+		if calls.Add(1) < 3 {
+			return consumer.ErrRetry
+		}
+		return nil
+	})
+
+	assert.NoError(t, handle([]byte(`{}`)))
+	assert.Equal(t, int32(3), calls.Load(), "message is retried, not dropped")
+}
+
+func TestGate_PermanentErrorIsNotRetried(t *testing.T) {
+	gate := consumer.NewGate(time.Millisecond)
+	defer gate.Close()
+
+	var calls atomic.Int32
+	invalid := errors.New("invalid order")
+	handle := gate.Wrap(func(data []byte) error {
+		calls.Add(1)
+		return invalid
+	})
+
+	assert.ErrorIs(t, handle(nil), invalid)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGate_CloseStopsWaiting(t *testing.T) {
+	gate := consumer.NewGate(time.Hour)
+	gate.Pause()
+
+	done := make(chan error, 1)
+	go func() { done <- gate.Wrap(func([]byte) error { return nil })(nil) }()
+
+	gate.Close()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, consumer.ErrStopped)
+	case <-time.After(time.Second):
+		t.Fatal("handler still waits after Close")
+	}
+}
+
+func TestMonitor_EntersAndLeavesDegradedMode(t *testing.T) {
+	b := breaker.New(1, 10*time.Millisecond, repository.IsInfrastructureError)
+	gate := consumer.NewGate(time.Hour)
+	defer gate.Close()
+
+	var dbUp atomic.Bool
+	monitor := health.NewMonitor(b, func() error {
+		if dbUp.Load() {
+			return nil
+		}
+		return connRefused()
+	}, gate, time.Hour)
+
+	_ = b.Do(func() error { return connRefused() })
+	require.Eventually(t, monitor.Degraded, time.Second, time.Millisecond)
+	assert.True(t, gate.Paused())
+	assert.Equal(t, "degraded", monitor.Status().Status)
+
+	time.Sleep(20 * time.Millisecond)
+	monitor.Probe()
+	assert.True(t, monitor.Degraded(), "failed probe keeps degraded mode")
+
+	dbUp.Store(true)
+	time.Sleep(20 * time.Millisecond)
+	monitor.Probe()
+	assert.False(t, monitor.Degraded())
+	assert.False(t, gate.Paused())
+	assert.Equal(t, "ok", monitor.Status().Status)
+}
+
+func TestOrderService_ApplyStatusMessage_RetriesWhenStorageUnavailable(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockRepo.On("FindByOrderUID", "u1").Return(nil, breaker.ErrOpen)
+	serv := service.NewOrderService(mockRepo, new(MockCache))
+
+	err := serv.ApplyStatusMessage([]byte(`{"order_uid":"u1","status":"paid"}`))
+
+	assert.ErrorIs(t, err, consumer.ErrRetry)
+	assert.ErrorIs(t, err, service.ErrUnavailable)
+}
+
+func TestOrderService_ApplyStatusMessage_NotFoundIsNotRetried(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockRepo.On("FindByOrderUID", "u1").Return(nil, gorm.ErrRecordNotFound)
+	serv := service.NewOrderService(mockRepo, new(MockCache))
+
+	err := serv.ApplyStatusMessage([]byte(`{"order_uid":"u1","status":"paid"}`))
+
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.NotErrorIs(t, err, consumer.ErrRetry)
+}
+
+func TestAdminHandler_StorageUnavailable(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockRepo.On("Purge", "u1").Return(breaker.ErrOpen)
+	r := chi.NewRouter()
+	r.Post("/admin/orders/{order_uid}/purge", handler.NewAdminHandler(service.NewOrderService(mockRepo, new(MockCache))).PurgeOrder)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/orders/u1/purge", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+}
+
+func TestGetOrderHistory_StorageUnavailable(t *testing.T) {
+	mockCache := new(MockCache)
+	mockCache.On("Get", "u1").Return(&models.Order{OrderUID: "u1"}, nil)
+	mockRepo := new(MockRepo)
+	mockRepo.On("GetStatusHistory", "u1").Return(nil, breaker.ErrOpen)
+	r := chi.NewRouter()
+	r.Get("/order/{order_uid}/history", handler.NewOrderHandler(service.NewOrderService(mockRepo, mockCache)).GetOrderHistory)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/order/u1/history", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "an outage is not a missing order")
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+}
@@ -0,0 +1,142 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	v1 "github.com/Sphirium/wb-tech-demo-lo/internal/api/v1"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/handler"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// applyFieldset возвращает выборку полей заказа в виде JSON-объекта
+func applyFieldset(t *testing.T, order *models.Order, fields, include string) map[string]any {
+	t.Helper()
+	fs, err := v1.ParseFieldset(fields, include)
+	require.NoError(t, err)
+	trimmed, err := fs.Apply(v1.FromOrder(order))
+	require.NoError(t, err)
+	data, err := json.Marshal(trimmed)
+	require.NoError(t, err)
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(data, &out))
+	return out
+}
+
+func TestFieldset(t *testing.T) {
+	order := loadModelOrder(t)
+
+	fs, err := v1.ParseFieldset("", "")
+	require.NoError(t, err)
+	assert.Nil(t, fs, "no selection means the full representation")
+	assert.True(t, fs.Includes(v1.AssocItems))
+
+	out := applyFieldset(t, order, "order_uid,payment.amount,items.name", "")
+	require.Len(t, out, 3)
+	assert.Equal(t, order.OrderUID, out["order_uid"])
+	assert.Equal(t, map[string]any{"amount": float64(order.Payment.Amount)}, out["payment"])
+	items := out["items"].([]any)
+	require.Len(t, items, len(order.Items))
+	assert.Equal(t, map[string]any{"name": order.Items[0].Name}, items[0])
+
+	// Только include: все поля верхнего уровня и перечисленные ассоциации целиком
+	out = applyFieldset(t, order, "", "payment")
+	assert.Contains(t, out, "track_number")
+	assert.Contains(t, out["payment"], "transaction")
+	assert.NotContains(t, out, "delivery")
+	assert.NotContains(t, out, "items")
+
+	// fields сужает ассоциацию из include, остальные включённые — целиком
+	out = applyFieldset(t, order, "order_uid,payment.currency", "payment,delivery")
+	assert.Equal(t, map[string]any{"currency": order.Payment.Currency}, out["payment"])
+	assert.Contains(t, out["delivery"], "city")
+	assert.NotContains(t, out, "track_number")
+
+	fs, err = v1.ParseFieldset("order_uid,items.name", "")
+	require.NoError(t, err)
+	assert.True(t, fs.Includes(v1.AssocItems))
+	assert.False(t, fs.Includes(v1.AssocPayment))
+	assert.False(t, fs.Includes(v1.AssocDelivery))
+
+	for _, bad := range [][2]string{{"nope", ""}, {"payment.nope", ""}, {"order_uid.x", ""}, {"", "status"}} {
+		_, err := v1.ParseFieldset(bad[0], bad[1])
+		assert.Error(t, err, "fields=%q include=%q", bad[0], bad[1])
+	}
+}
+
+func TestGetOrder_FieldsetPreloadsOnlyRequested(t *testing.T) {
+	order := loadModelOrder(t)
+	partial := *order
+	partial.Delivery, partial.Items = nil, nil
+
+	mockCache := new(MockCache)
+	mockCache.On("Get", order.OrderUID).Return(nil, errors.New("miss"))
+	mockRepo := new(MockRepo)
+	mockRepo.On("FindByOrderUIDWith", order.OrderUID, repository.Include{Payment: true}).Return(&partial, nil)
+	serv := service.NewOrderService(mockRepo, mockCache)
+
+	r := chi.NewRouter()
+	r.Get("/v1/order/{order_uid}", handler.NewOrderHandler(serv).GetOrder)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/order/"+order.OrderUID+"?fields=order_uid,payment.amount", nil)
+	req = req.WithContext(pii.WithRole(req.Context(), pii.RolePublic))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"order_uid":"`+order.OrderUID+`","payment":{"amount":`+strconv.Itoa(order.Payment.Amount)+`}}`, rec.Body.String())
+	assert.Equal(t, `"`+v1.ContentVersion(rec.Body.Bytes())+`"`, rec.Header().Get("ETag"))
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "Set", mock.Anything)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/order/"+order.OrderUID+"?include=comments", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestFieldset_LookupAndListEndpoints(t *testing.T) {
+	order := loadModelOrder(t)
+	partial := *order
+	partial.Delivery, partial.Items = nil, nil
+
+	mockCache := new(MockCache)
+	mockCache.On("GetByTrackNumber", order.TrackNumber).Return(nil, errors.New("miss"))
+	mockCache.On("GetMany", []string{order.OrderUID}).Return(map[string]*models.Order{order.OrderUID: order}, nil)
+	mockRepo := new(MockRepo)
+	mockRepo.On("FindByTrackNumber", order.TrackNumber, repository.Include{Payment: true}).Return(&partial, nil)
+	h := handler.NewOrderHandler(service.NewOrderService(mockRepo, mockCache))
+
+	r := chi.NewRouter()
+	r.Get("/order/by-track/{track}", h.GetOrderByTrack)
+	r.Post("/orders:batchGet", h.BatchGetOrders)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/order/by-track/"+order.TrackNumber+"?fields=order_uid,payment.amount", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"order_uid":"`+order.OrderUID+`","payment":{"amount":`+strconv.Itoa(order.Payment.Amount)+`}}`, rec.Body.String())
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertNotCalled(t, "Set", mock.Anything)
+
+	rec = httptest.NewRecorder()
+	body := strings.NewReader(`{"order_uids":["` + order.OrderUID + `"]}`)
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders:batchGet?fields=order_uid,items.name", body))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"orders":[{"order_uid":"`+order.OrderUID+`","items":[{"name":"`+order.Items[0].Name+`"}]}],"missing":[]}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders:batchGet?include=comments", strings.NewReader(`{"order_uids":[]}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
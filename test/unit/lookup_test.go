@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOrderService_GetOrderByTrackNumber_CacheHit(t *testing.T) {
+	mockCache := new(MockCache)
+	mockRepo := new(MockRepo)
+	expected := &models.Order{OrderUID: "u1", TrackNumber: "T1"}
+	mockCache.On("GetByTrackNumber", "T1").Return(expected, nil)
+	serv := service.NewOrderService(mockRepo, mockCache)
+
+	order, err := serv.GetOrderByTrackNumber("T1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, order)
+	mockRepo.AssertNotCalled(t, "FindByTrackNumber", mock.Anything, mock.Anything)
+}
+
+func TestOrderService_GetOrderByTransaction_MissFillsCache(t *testing.T) {
+	mockCache := new(MockCache)
+	mockRepo := new(MockRepo)
+	expected := &models.Order{OrderUID: "u1", Payment: &models.Payment{Transaction: "tx1"}}
+	mockCache.On("GetByTransaction", "tx1").Return(nil, errors.New("redis: nil"))
+	mockRepo.On("FindByTransaction", "tx1", repository.IncludeAll).Return(expected, nil)
+	mockCache.On("Set", expected).Return(nil)
+	serv := service.NewOrderService(mockRepo, mockCache)
+
+	order, err := serv.GetOrderByTransaction("tx1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, order)
+	mockCache.AssertExpectations(t)
+}
+
+func TestOrderService_GetCustomerOrders_PagesAndSkipsDeleted(t *testing.T) {
+	mockCache := new(MockCache)
+	mockRepo := new(MockRepo)
+	uids := []string{uuid.NewString(), uuid.NewString(), uuid.NewString(), uuid.NewString()}
+	page := uids[1:3]
+	mockCache.On("GetCustomerOrderUIDs", "c1").Return(nil, errors.New("redis: nil"))
+	mockRepo.On("FindOrderUIDsByCustomer", "c1").Return(uids, nil)
+	mockCache.On("SetCustomerOrderUIDs", "c1", uids).Return(nil)
+	mockCache.On("GetMany", page).Return(map[string]*models.Order{page[0]: {OrderUID: page[0]}}, nil)
+	mockRepo.On("FindByOrderUIDs", page[1:]).Return([]models.Order{}, nil)
+	serv := service.NewOrderService(mockRepo, mockCache)
+
+	orders, total, err := serv.GetCustomerOrders("c1", 2, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, total)
+	assert.Len(t, orders, 1)
+	assert.Equal(t, page[0], orders[0].OrderUID)
+	mockCache.AssertExpectations(t)
+}
+
+func TestOrderService_GetOrdersByUIDs_CacheHitsAndSingleQueryForMisses(t *testing.T) {
+	mockCache := new(MockCache)
+	mockRepo := new(MockRepo)
+	hit, miss, absent := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	loaded := models.Order{OrderUID: miss}
+
+	mockCache.On("GetMany", []string{hit, miss, absent}).Return(map[string]*models.Order{hit: {OrderUID: hit}}, nil)
+	mockRepo.On("FindByOrderUIDs", []string{miss, absent}).Return([]models.Order{loaded}, nil).Once()
+	mockCache.On("Set", mock.MatchedBy(func(o *models.Order) bool { return o.OrderUID == miss })).Return(nil)
+	serv := service.NewOrderService(mockRepo, mockCache)
+
+	found, missing, err := serv.GetOrdersByUIDs([]string{hit, miss, hit, absent})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{hit, miss}, []string{found[0].OrderUID, found[1].OrderUID})
+	assert.Equal(t, []string{absent}, missing)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestOrderService_GetOrdersByUIDs_RejectsInvalidUIDs(t *testing.T) {
+	serv := service.NewOrderService(new(MockRepo), new(MockCache))
+
+	_, _, err := serv.GetOrdersByUIDs([]string{uuid.NewString(), "not-a-uuid"})
+
+	var verr *service.ValidationError
+	assert.ErrorAs(t, err, &verr)
+	assert.Contains(t, verr.Fields, "order_uids[1]")
+}
@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/money"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRates = `{
+	"base": "RUB",
+	"versions": [
+		{"date": "2024-01-01", "rates": {"USD": "90", "EUR": "100", "JPY": "0.6"}},
+		{"date": "2021-11-01", "rates": {"USD": "70", "EUR": "80"}}
+	]
+}`
+
+func TestMoney_ParsePrecision(t *testing.T) {
+	m, err := money.Parse("18.17", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1817), m.Amount)
+
+	_, err = money.Parse("18.175", "USD")
+	assert.ErrorIs(t, err, money.ErrPrecision)
+
+	_, err = money.Parse("1.5", "JPY")
+	assert.ErrorIs(t, err, money.ErrPrecision)
+
+	m, err = money.Parse("1.234", "KWD")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1234), m.Amount)
+
+	for _, code := range []string{"THB", "EGP", "CLF"} {
+		_, err = money.Parse("1", code)
+		assert.NoError(t, err, code)
+	}
+
+	for _, code := range []string{"usd", "XXX", "US"} {
+		_, err = money.Parse("1", code)
+		assert.ErrorIs(t, err, money.ErrUnknownCurrency, code)
+	}
+}
+
+func TestMoney_JSONRoundTrip(t *testing.T) {
+	for _, m := range []money.Money{
+		{Amount: 1817, Currency: "USD"},
+		{Amount: -5, Currency: "EUR"},
+		{Amount: 1500, Currency: "JPY"},
+	} {
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+
+		var decoded money.Money
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, m, decoded, string(data))
+	}
+
+	data, _ := json.Marshal(money.Money{Amount: 1817, Currency: "USD"})
+	assert.JSONEq(t, `{"amount":"18.17","currency":"USD"}`, string(data))
+}
+
+func TestRateTable_ConvertPicksVersionByDate(t *testing.T) {
+	rates, err := money.ParseRates(strings.NewReader(testRates))
+	require.NoError(t, err)
+	usd, _ := money.FromMajor(10, "USD")
+
+	rub, rateDate, err := rates.Convert(usd, "RUB", time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, int64(70000), rub.Amount)
+	assert.Equal(t, "2021-11-01", rateDate.Format(time.DateOnly))
+
+	rub, rateDate, err = rates.Convert(usd, "RUB", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, int64(90000), rub.Amount)
+	assert.Equal(t, "2024-01-01", rateDate.Format(time.DateOnly))
+
+	_, _, err = rates.Convert(usd, "RUB", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, money.ErrNoRate)
+
+	// JPY появился только во второй версии
+	_, _, err = rates.Convert(usd, "JPY", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, money.ErrNoRate)
+}
+
+func TestRateTable_ConvertRoundsHalfEven(t *testing.T) {
+	rates, err := money.ParseRates(strings.NewReader(`{"base":"USD","versions":[{"date":"2024-01-01","rates":{"EUR":"0.5"}}]}`))
+	require.NoError(t, err)
+	at := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	// 0.05 EUR = 0.025 USD -> 0.02; 0.07 EUR = 0.035 USD -> 0.04
+	for minor, expected := range map[int64]int64{5: 2, 7: 4, -5: -2} {
+		converted, _, err := rates.Convert(money.Money{Amount: minor, Currency: "EUR"}, "USD", at)
+		require.NoError(t, err)
+		assert.Equal(t, expected, converted.Amount, minor)
+	}
+}
+
+func TestValidateOrder_RejectsUnknownCurrency(t *testing.T) {
+	for _, code := range []string{"usd", "XXX"} {
+		order := &models.Order{Payment: &models.Payment{Currency: code}}
+
+		var verr *service.ValidationError
+		require.ErrorAs(t, service.ValidateOrder(order), &verr)
+		assert.Contains(t, verr.Fields, "payment.currency", code)
+	}
+}
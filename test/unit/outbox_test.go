@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/outbox"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOutboxRepo хранит события в памяти и помечает их отправленными после успешной публикации
+type fakeOutboxRepo struct {
+	pending []models.OutboxEvent
+}
+
+func (r *fakeOutboxRepo) ProcessPending(limit int, publish func([]models.OutboxEvent) error) (int, error) {
+	n := min(limit, len(r.pending))
+	if n == 0 {
+		return 0, nil
+	}
+	if err := publish(r.pending[:n]); err != nil {
+		return 0, err
+	}
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func (r *fakeOutboxRepo) DeleteSentBefore(time.Time) (int64, error) { return 0, nil }
+
+type fakePublisher struct {
+	published []models.OutboxEvent
+	err       error
+}
+
+func (p *fakePublisher) Publish(_ context.Context, events []models.OutboxEvent) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.published = append(p.published, events...)
+	return nil
+}
+
+func (p *fakePublisher) Close() error { return nil }
+
+var _ repository.OutboxRepositoryInterface = (*fakeOutboxRepo)(nil)
+
+func TestRelay_FlushDrainsQueue(t *testing.T) {
+	repo := &fakeOutboxRepo{}
+	for i := 0; i < 250; i++ {
+		repo.pending = append(repo.pending, models.OutboxEvent{ID: int64(i), EventType: models.EventOrderCreated})
+	}
+	pub := &fakePublisher{}
+
+	outbox.NewRelay(repo, pub, time.Second, time.Hour).Flush()
+
+	assert.Len(t, pub.published, 250)
+	assert.Empty(t, repo.pending)
+}
+
+func TestRelay_FailedPublishKeepsEvents(t *testing.T) {
+	repo := &fakeOutboxRepo{pending: []models.OutboxEvent{{ID: 1}}}
+	pub := &fakePublisher{err: errors.New("broker down")}
+
+	outbox.NewRelay(repo, pub, time.Second, time.Hour).Flush()
+
+	assert.Len(t, repo.pending, 1)
+}
@@ -0,0 +1,41 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/partition"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePartitionRepo struct {
+	defaults map[string][]time.Time
+	created  map[string][]string
+}
+
+func (r *fakePartitionRepo) IsPartitioned(table string) (bool, error) { return table == "orders", nil }
+
+func (r *fakePartitionRepo) DefaultMonths(table string) ([]time.Time, error) {
+	return r.defaults[table], nil
+}
+
+func (r *fakePartitionRepo) EnsureMonth(table string, month time.Time) (bool, error) {
+	r.created[table] = append(r.created[table], month.Format("2006-01"))
+	return true, nil
+}
+
+var _ repository.PartitionRepositoryInterface = (*fakePartitionRepo)(nil)
+
+func TestMaintainer_CreatesAheadAndSplitsDefault(t *testing.T) {
+	repo := &fakePartitionRepo{
+		defaults: map[string][]time.Time{"orders": {time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)}},
+		created:  map[string][]string{},
+	}
+
+	err := partition.NewMaintainer(repo, 2, time.Hour).RunOnce(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2021-11", "2023-12", "2024-01", "2024-02", "2024-03"}, repo.created["orders"])
+	assert.Empty(t, repo.created["items"])
+}
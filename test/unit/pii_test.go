@@ -0,0 +1,202 @@
+package unit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	v1 "github.com/Sphirium/wb-tech-demo-lo/internal/api/v1"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+)
+
+func TestMasks(t *testing.T) {
+	assert.Equal(t, "+972****000", pii.MaskPhone("+9720000000"))
+	assert.Equal(t, "****", pii.MaskPhone("12345"))
+	assert.Equal(t, "t***@gmail.com", pii.MaskEmail("test@gmail.com"))
+	assert.Equal(t, "***", pii.MaskEmail("not-an-email"))
+	assert.Equal(t, "T*** T***", pii.MaskName("Test Testov"))
+	assert.Equal(t, "Т***", pii.MaskName("Тест"))
+	assert.Equal(t, "***", pii.MaskAll("Ploshad Mira 15"))
+	for _, mask := range []pii.Mask{pii.MaskPhone, pii.MaskEmail, pii.MaskName, pii.MaskAll} {
+		assert.Equal(t, "", mask(""))
+	}
+}
+
+func TestV1Order_RedactByRole(t *testing.T) {
+	order := loadModelOrder(t)
+
+	public := v1.FromOrder(order)
+	public.Redact(pii.PolicyFor(pii.RolePublic))
+	assert.Equal(t, "T*** T***", public.Delivery.Name)
+	assert.Equal(t, "+972****000", public.Delivery.Phone)
+	assert.Equal(t, "t***@gmail.com", public.Delivery.Email)
+	assert.Equal(t, "***", public.Delivery.Address)
+	assert.Equal(t, "***", public.Payment.Bank)
+	assert.Equal(t, order.Delivery.City, public.Delivery.City)
+
+	order.InternalSignature = "sig-secret"
+	public = v1.FromOrder(order)
+	public.Redact(pii.PolicyFor(pii.RolePublic))
+	assert.Equal(t, "***", public.InternalSignature)
+
+	support := v1.FromOrder(order)
+	support.Redact(pii.PolicyFor(pii.RoleSupport))
+	assert.Equal(t, "***", support.InternalSignature)
+	assert.Equal(t, order.Delivery.Name, support.Delivery.Name)
+	assert.Equal(t, "+972****000", support.Delivery.Phone)
+
+	admin := v1.FromOrder(order)
+	admin.Redact(pii.PolicyFor(pii.RoleAdmin))
+	assert.Equal(t, v1.FromOrder(order), admin)
+
+	// Маскирование не меняет доменный заказ
+	assert.Equal(t, "+9720000000", order.Delivery.Phone)
+}
+
+func TestMaskJSON_ForLogs(t *testing.T) {
+	data, err := json.Marshal(v1.FromOrder(loadModelOrder(t)))
+	require.NoError(t, err)
+
+	masked := string(pii.MaskJSON(data, pii.LogPolicy()))
+	assert.NotContains(t, masked, "+9720000000")
+	assert.NotContains(t, masked, "test@gmail.com")
+	assert.NotContains(t, masked, "Test Testov")
+	assert.Contains(t, masked, `"+972****000"`)
+	assert.Contains(t, masked, `"b563feb7-b2b8-4b6e-8e57-5e57000b2b84"`)
+
+	assert.Equal(t, "<9 bytes, not JSON>", string(pii.MaskJSON([]byte("name=Test"), pii.LogPolicy())))
+}
+
+func testKeyring(t *testing.T, active string, ids ...string) *pii.Keyring {
+	t.Helper()
+	keys := map[string][]byte{}
+	for _, id := range ids {
+		keys[id] = []byte(strings.Repeat(id[:1], 32))
+	}
+	k, err := pii.NewKeyring(active, keys)
+	require.NoError(t, err)
+	return k
+}
+
+func TestKeyring_RoundTrip(t *testing.T) {
+	k := testKeyring(t, "a", "a")
+
+	encrypted, err := k.Encrypt("+9720000000")
+	require.NoError(t, err)
+	assert.True(t, pii.IsEncrypted(encrypted))
+	assert.NotContains(t, encrypted, "9720000000")
+	kid, ok := pii.KeyID(encrypted)
+	assert.True(t, ok)
+	assert.Equal(t, "a", kid)
+
+	again, _ := k.Encrypt("+9720000000")
+	assert.NotEqual(t, encrypted, again, "data key and nonce must be random")
+
+	plain, err := k.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "+9720000000", plain)
+
+	// Записанное до включения шифрования читается как есть
+	plain, err = k.Decrypt("+9720000000")
+	require.NoError(t, err)
+	assert.Equal(t, "+9720000000", plain)
+
+	_, err = k.Decrypt(encrypted[:len(encrypted)-4] + "AAAA")
+	assert.Error(t, err)
+
+	_, err = pii.ParseKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.Error(t, err)
+	_, err = pii.NewKeyring("missing", map[string][]byte{"a": []byte(strings.Repeat("a", 32))})
+	assert.Error(t, err)
+}
+
+func TestKeyring_Rotate(t *testing.T) {
+	old := testKeyring(t, "a", "a")
+	encrypted, err := old.Encrypt("Test Testov")
+	require.NoError(t, err)
+
+	rotated := testKeyring(t, "b", "a", "b")
+	value, changed, err := rotated.Rotate(encrypted)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	kid, _ := pii.KeyID(value)
+	assert.Equal(t, "b", kid)
+
+	// Без старого ключа перешифрованное значение читается
+	onlyNew := testKeyring(t, "b", "b")
+	plain, err := onlyNew.Decrypt(value)
+	require.NoError(t, err)
+	assert.Equal(t, "Test Testov", plain)
+	_, err = onlyNew.Decrypt(encrypted)
+	assert.ErrorIs(t, err, pii.ErrUnknownKey)
+
+	// Под активным ключом значение не меняется; открытое — шифруется
+	same, changed, err := rotated.Rotate(value)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, value, same)
+
+	value, changed, err = rotated.Rotate("Test Testov")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	plain, _ = onlyNew.Decrypt(value)
+	assert.Equal(t, "Test Testov", plain)
+
+	_, changed, _ = rotated.Rotate("")
+	assert.False(t, changed)
+}
+
+func TestParseKeyring(t *testing.T) {
+	keyA := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32)))
+	keyB := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("b", 32)))
+
+	k, err := pii.ParseKeyring(strings.NewReader(`{"active":"2024-06","keys":{"2024-01":"` + keyA + `","2024-06":"` + keyB + `"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "2024-06", k.Active())
+
+	_, err = pii.ParseKeyring(strings.NewReader(`{"active":"2024-06","keys":{"2024-06":"c2hvcnQ="}}`))
+	assert.Error(t, err)
+
+	// Один ключ из PII_ENCRYPTION_KEY — под идентификатором legacy
+	k, err = pii.OpenKeyring("", keyA)
+	require.NoError(t, err)
+	assert.Equal(t, pii.LegacyKeyID, k.Active())
+
+	k, err = pii.OpenKeyring("", "")
+	require.NoError(t, err)
+	assert.Nil(t, k)
+}
+
+func TestPIISerializer_EncryptsWhenKeyIsSet(t *testing.T) {
+	s, err := schema.Parse(&models.Delivery{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+	field := s.LookUpField("phone")
+	require.NotNil(t, field)
+	ctx := context.Background()
+
+	stored, err := pii.Serializer{}.Value(ctx, field, reflect.Value{}, "+9720000000")
+	require.NoError(t, err)
+	assert.Equal(t, "+9720000000", stored, "without a key values are stored as is")
+
+	pii.SetKeyring(testKeyring(t, "a", "a"))
+	defer pii.SetKeyring(nil)
+
+	stored, err = pii.Serializer{}.Value(ctx, field, reflect.Value{}, "+9720000000")
+	require.NoError(t, err)
+	assert.True(t, pii.IsEncrypted(stored.(string)))
+
+	var d models.Delivery
+	require.NoError(t, pii.Serializer{}.Scan(ctx, field, reflect.ValueOf(&d).Elem(), stored))
+	assert.Equal(t, "+9720000000", d.Phone)
+
+	pii.SetKeyring(nil)
+	assert.ErrorIs(t, pii.Serializer{}.Scan(ctx, field, reflect.ValueOf(&d).Elem(), stored), pii.ErrNoKey)
+}
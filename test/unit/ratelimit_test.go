@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/auth"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/handler"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	l, err := ratelimit.ParseLimit("120/m:10")
+	require.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Rate: 2, Burst: 10}, l)
+	assert.Equal(t, 5*time.Second, l.Window())
+
+	l, err = ratelimit.ParseLimit("20/s")
+	require.NoError(t, err)
+	assert.Equal(t, 20, l.Burst, "burst defaults to the count per period")
+
+	l, err = ratelimit.ParseLimit("off")
+	require.NoError(t, err)
+	assert.True(t, l.Unlimited())
+
+	for _, bad := range []string{"20", "20/d", "x/s", "0/s", "20/s:0"} {
+		_, err := ratelimit.ParseLimit(bad)
+		assert.Error(t, err, bad)
+	}
+
+	limits, err := ratelimit.ParseLimits([]string{"read=20/s:40", "write=off"})
+	require.NoError(t, err)
+	assert.Equal(t, 40, limits["read"].Burst)
+	_, err = ratelimit.ParseLimits([]string{"20/s"})
+	assert.Error(t, err)
+}
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := ratelimit.NewMemoryStore().WithClock(func() time.Time { return now })
+	limit := ratelimit.Limit{Rate: 1, Burst: 3}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		res, err := store.Take(ctx, "c", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, i, res.Remaining)
+	}
+	res, _ := store.Take(ctx, "c", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 3*time.Second, res.Reset)
+
+	// Другой клиент — своё ведро
+	res, _ = store.Take(ctx, "other", limit)
+	assert.True(t, res.Allowed)
+
+	now = now.Add(1500 * time.Millisecond)
+	res, _ = store.Take(ctx, "c", limit)
+	assert.True(t, res.Allowed)
+	res, _ = store.Take(ctx, "c", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	// Наполнившиеся вёдра забываются
+	now = now.Add(time.Hour)
+	store.Take(ctx, "c", limit)
+	assert.Equal(t, 1, store.Len())
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("redis: connection refused")
+}
+
+func TestLimiter_FallsBackToMemoryWhenStoreFails(t *testing.T) {
+	l := ratelimit.New(failingStore{}, map[string]ratelimit.Limit{"read": {Rate: 0.001, Burst: 1}})
+	assert.True(t, l.Take(context.Background(), "read", "ip:1.2.3.4").Allowed)
+	assert.False(t, l.Take(context.Background(), "read", "ip:1.2.3.4").Allowed, "limits still apply without Redis")
+	assert.True(t, l.Take(context.Background(), "unlimited", "ip:1.2.3.4").Allowed)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{"read": {Rate: 0.5, Burst: 2}})
+	a := auth.NewAuthenticator([]auth.APIKey{auth.NewAPIKey("reports", "k", auth.ScopeRead)}, nil)
+	h := handler.Authenticate(a, []auth.Scope{auth.ScopeRead}, pii.RolePublic)(
+		handler.RateLimit(limiter, "read")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	do := func(remoteAddr, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/order/x", nil)
+		req.RemoteAddr = remoteAddr
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("10.0.0.1:5000", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2;w=4", rec.Header().Get("RateLimit-Policy"))
+
+	// Анонимные клиенты различаются по IP, порт не важен
+	assert.Equal(t, http.StatusOK, do("10.0.0.1:5001", "").Code)
+	rec = do("10.0.0.1:5002", "")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, http.StatusOK, do("10.0.0.2:5000", "").Code)
+
+	// Аутентифицированный клиент — по ключу, с любого адреса
+	assert.Equal(t, http.StatusOK, do("10.0.0.1:5003", "k").Code)
+	assert.Equal(t, http.StatusOK, do("10.0.0.3:5000", "k").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("10.0.0.4:5000", "k").Code)
+
+	// Класс без лимита не ограничивается и заголовков не ставит
+	open := handler.RateLimit(limiter, "stats")(http.NotFoundHandler())
+	rec = httptest.NewRecorder()
+	open.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
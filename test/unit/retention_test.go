@@ -0,0 +1,180 @@
+package unit
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/pii"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/retention"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeArchiveRepo отдаёт заказы один раз и хранит строки архива в памяти
+type fakeArchiveRepo struct {
+	orders  []models.Order
+	records map[string]models.ArchiveRecord
+}
+
+func (r *fakeArchiveRepo) ArchiveBefore(_ time.Time, limit int, store func([]models.ArchivedOrder) ([]models.ArchiveRecord, error)) (int, error) {
+	n := min(limit, len(r.orders))
+	if n == 0 {
+		return 0, nil
+	}
+	snapshots := make([]models.ArchivedOrder, n)
+	for i := range snapshots {
+		snapshots[i] = models.ArchivedOrder{Order: &r.orders[i]}
+	}
+	records, err := store(snapshots)
+	if err != nil {
+		return 0, err
+	}
+	for _, rec := range records {
+		r.records[rec.OrderUID] = rec
+	}
+	r.orders = r.orders[n:]
+	return n, nil
+}
+
+func (r *fakeArchiveRepo) FindArchived(uid string) (*models.ArchiveRecord, error) {
+	rec, ok := r.records[uid]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &rec, nil
+}
+
+var _ repository.ArchiveRepositoryInterface = (*fakeArchiveRepo)(nil)
+
+func TestArchiver_RoundTrip(t *testing.T) {
+	for _, storage := range []string{models.ArchiveStorageTable, models.ArchiveStorageFile} {
+		t.Run(storage, func(t *testing.T) {
+			repo := &fakeArchiveRepo{records: map[string]models.ArchiveRecord{}}
+			for _, uid := range []string{"u1", "u2", "u3"} {
+				repo.orders = append(repo.orders, models.Order{
+					OrderUID:    uid,
+					DateCreated: models.NewTimestamp(time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)),
+					Items:       []models.Item{{ChrtID: 1, Name: "item-" + uid}},
+				})
+			}
+			mockCache := new(MockCache)
+			mockCache.On("Delete", mock.Anything).Return(nil)
+
+			archiver := retention.NewArchiver(repo, mockCache, retention.Policy{
+				HotDays:   180,
+				Storage:   storage,
+				Dir:       t.TempDir(),
+				BatchSize: 2,
+			})
+
+			n, err := archiver.RunOnce()
+			assert.NoError(t, err)
+			assert.Equal(t, 3, n)
+			mockCache.AssertNumberOfCalls(t, "Delete", 3)
+
+			order, err := archiver.FindArchived("u2")
+			assert.NoError(t, err)
+			assert.Equal(t, "item-u2", order.Items[0].Name)
+
+			_, err = archiver.FindArchived("missing")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestArchiver_KeepsCancelledOrdersHidden(t *testing.T) {
+	deletedAt := time.Date(2022, 1, 5, 0, 0, 0, 0, time.UTC)
+	repo := &fakeArchiveRepo{records: map[string]models.ArchiveRecord{}, orders: []models.Order{{
+		OrderUID:    "u1",
+		DateCreated: models.NewTimestamp(time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)),
+		DeletedAt:   gorm.DeletedAt{Time: deletedAt, Valid: true},
+	}}}
+	mockCache := new(MockCache)
+	mockCache.On("Delete", mock.Anything).Return(nil)
+	archiver := retention.NewArchiver(repo, mockCache, retention.Policy{HotDays: 180, Storage: models.ArchiveStorageTable})
+
+	_, err := archiver.RunOnce()
+	require.NoError(t, err)
+	assert.Equal(t, gorm.DeletedAt{Time: deletedAt, Valid: true}, repo.records["u1"].DeletedAt)
+}
+
+func TestArchiver_EncryptsPIIAndReencryptsFiles(t *testing.T) {
+	pii.SetKeyring(testKeyring(t, "a", "a"))
+	defer pii.SetKeyring(nil)
+
+	dir := t.TempDir()
+	repo := &fakeArchiveRepo{records: map[string]models.ArchiveRecord{}, orders: []models.Order{{
+		OrderUID:          "u1",
+		InternalSignature: "sig-secret",
+		DateCreated:       models.NewTimestamp(time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)),
+		Delivery:          &models.Delivery{Name: "Test Testov", Phone: "+9720000000", City: "Kiryat Mozkin"},
+	}}}
+	mockCache := new(MockCache)
+	mockCache.On("Delete", mock.Anything).Return(nil)
+	archiver := retention.NewArchiver(repo, mockCache, retention.Policy{HotDays: 180, Storage: models.ArchiveStorageFile, Dir: dir})
+
+	source := repo.orders[0].Delivery
+	_, err := archiver.RunOnce()
+	require.NoError(t, err)
+	assert.Equal(t, "+9720000000", source.Phone, "the source order is not modified")
+
+	readArchive := func() string {
+		f, err := os.Open(filepath.Join(dir, "orders-2021-11.jsonl.gz"))
+		require.NoError(t, err)
+		defer f.Close()
+		zr, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		data, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		return string(data)
+	}
+	content := readArchive()
+	assert.NotContains(t, content, "+9720000000")
+	assert.NotContains(t, content, "sig-secret")
+	assert.Contains(t, content, "Kiryat Mozkin", "non-PII fields stay readable")
+
+	order, err := archiver.FindArchived("u1")
+	require.NoError(t, err)
+	assert.Equal(t, "+9720000000", order.Delivery.Phone)
+	assert.Equal(t, "sig-secret", order.InternalSignature)
+
+	// Ротация: файлы переводятся на новый ключ, старый больше не нужен
+	n, err := retention.ReencryptFiles(dir, testKeyring(t, "b", "a", "b"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Contains(t, readArchive(), "Kiryat Mozkin")
+
+	pii.SetKeyring(testKeyring(t, "b", "b"))
+	order, err = archiver.FindArchived("u1")
+	require.NoError(t, err)
+	assert.Equal(t, "Test Testov", order.Delivery.Name)
+
+	n, err = retention.ReencryptFiles(dir, testKeyring(t, "b", "b"))
+	require.NoError(t, err)
+	assert.Zero(t, n, "values under the active key are left as is")
+}
+
+func TestMapSnapshotPII(t *testing.T) {
+	doc := []byte(`{"event_type":"order.created","order":{"order_uid":"u1","internal_signature":"sig",` +
+		`"delivery":{"name":"Test Testov","city":"Kiryat Mozkin"},"date_created":"2021-11-26T06:22:19+03:00"}}`)
+	upper := func(s string) (string, error) { return strings.ToUpper(s), nil }
+
+	out, err := models.MapSnapshotPII(doc, upper)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"event_type":"order.created","order":{"order_uid":"u1","internal_signature":"SIG",`+
+		`"delivery":{"name":"TEST TESTOV","city":"Kiryat Mozkin"},"date_created":"2021-11-26T06:22:19+03:00"}}`, string(out))
+
+	purged := []byte(`{"event_type":"order.purged","order_uid":"u1"}`)
+	out, err = models.MapSnapshotPII(purged, upper)
+	require.NoError(t, err)
+	assert.Equal(t, purged, out)
+}
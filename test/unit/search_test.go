@@ -0,0 +1,43 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextQuery_PrefixTermsAndEscaping(t *testing.T) {
+	assert.Equal(t, "'иван':* & 'москва':*", repository.TextQuery("  Иван   Москва "))
+	assert.Equal(t, "'test@gmail.com':*", repository.TextQuery("test@gmail.com"))
+	assert.Equal(t, "'o':* & 'brien':* & 'drop':*", repository.TextQuery(`O'Brien & !drop:*`))
+	assert.Equal(t, "", repository.TextQuery(" ' & | "))
+}
+
+func TestOrderService_SearchOrders_RequiresCriterion(t *testing.T) {
+	mockRepo := new(MockRepo)
+	serv := service.NewOrderService(mockRepo, new(MockCache))
+
+	_, err := serv.SearchOrders(&repository.OrderSearch{Text: " & "})
+
+	var verr *service.ValidationError
+	assert.ErrorAs(t, err, &verr)
+	assert.Contains(t, verr.Fields, "q")
+	mockRepo.AssertNotCalled(t, "Search")
+}
+
+func TestOrderService_SearchOrders_DefaultLimit(t *testing.T) {
+	mockRepo := new(MockRepo)
+	expected := []models.Order{{OrderUID: "u1"}}
+	mockRepo.On("Search", repository.OrderSearch{NMID: 42, Limit: 20}).Return(expected, nil)
+	serv := service.NewOrderService(mockRepo, new(MockCache))
+
+	search := &repository.OrderSearch{NMID: 42}
+	orders, err := serv.SearchOrders(search)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, orders)
+	assert.Equal(t, 20, search.Limit)
+}
@@ -1,14 +1,18 @@
 package unit
 
 import (
+	"os"
 	"testing"
+	"time"
 
 	"github.com/Sphirium/wb-tech-demo-lo/internal/cache"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/consumer"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
 	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockRepo struct{ mock.Mock }
@@ -24,6 +28,41 @@ func (m *MockRepo) FindByOrderUID(uid string) (*models.Order, error) {
 	}
 	return nil, args.Error(1)
 }
+func (m *MockRepo) FindByOrderUIDWith(uid string, include repository.Include) (*models.Order, error) {
+	args := m.Called(uid, include)
+	if result := args.Get(0); result != nil {
+		return result.(*models.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+func (m *MockRepo) FindByOrderUIDs(uids []string) ([]models.Order, error) {
+	args := m.Called(uids)
+	if result := args.Get(0); result != nil {
+		return result.([]models.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+func (m *MockRepo) FindByTrackNumber(track string, include repository.Include) (*models.Order, error) {
+	args := m.Called(track, include)
+	if result := args.Get(0); result != nil {
+		return result.(*models.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+func (m *MockRepo) FindByTransaction(tx string, include repository.Include) (*models.Order, error) {
+	args := m.Called(tx, include)
+	if result := args.Get(0); result != nil {
+		return result.(*models.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+func (m *MockRepo) FindOrderUIDsByCustomer(customerID string) ([]string, error) {
+	args := m.Called(customerID)
+	if result := args.Get(0); result != nil {
+		return result.([]string), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
 func (m *MockRepo) GetAllOrderUIDs() ([]string, error) {
 	args := m.Called()
 	if result := args.Get(0); result != nil {
@@ -32,6 +71,37 @@ func (m *MockRepo) GetAllOrderUIDs() ([]string, error) {
 	return nil, args.Error(1)
 }
 
+func (m *MockRepo) ChangeStatus(uid string, from, to models.OrderStatus, reason string, at time.Time) (*models.Order, error) {
+	args := m.Called(uid, from, to, reason, at)
+	if result := args.Get(0); result != nil {
+		return result.(*models.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+func (m *MockRepo) GetStatusHistory(uid string) ([]models.StatusHistory, error) {
+	args := m.Called(uid)
+	if result := args.Get(0); result != nil {
+		return result.([]models.StatusHistory), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepo) SoftDelete(uid string, at time.Time) error {
+	args := m.Called(uid, at)
+	return args.Error(0)
+}
+func (m *MockRepo) Purge(uid string) error {
+	args := m.Called(uid)
+	return args.Error(0)
+}
+func (m *MockRepo) Search(search repository.OrderSearch) ([]models.Order, error) {
+	args := m.Called(search)
+	if result := args.Get(0); result != nil {
+		return result.([]models.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 type MockCache struct{ mock.Mock }
 
 func (m *MockCache) Set(order *models.Order) error {
@@ -45,6 +115,46 @@ func (m *MockCache) Get(uid string) (*models.Order, error) {
 	}
 	return nil, args.Error(1)
 }
+func (m *MockCache) GetMany(uids []string) (map[string]*models.Order, error) {
+	args := m.Called(uids)
+	if result := args.Get(0); result != nil {
+		return result.(map[string]*models.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+func (m *MockCache) Delete(uid string) error {
+	args := m.Called(uid)
+	return args.Error(0)
+}
+func (m *MockCache) GetByTrackNumber(track string) (*models.Order, error) {
+	args := m.Called(track)
+	if result := args.Get(0); result != nil {
+		return result.(*models.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+func (m *MockCache) GetByTransaction(tx string) (*models.Order, error) {
+	args := m.Called(tx)
+	if result := args.Get(0); result != nil {
+		return result.(*models.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+func (m *MockCache) GetCustomerOrderUIDs(customerID string) ([]string, error) {
+	args := m.Called(customerID)
+	if result := args.Get(0); result != nil {
+		return result.([]string), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+func (m *MockCache) SetCustomerOrderUIDs(customerID string, uids []string) error {
+	args := m.Called(customerID, uids)
+	return args.Error(0)
+}
+func (m *MockCache) DeleteCustomerOrderUIDs(customerID string) error {
+	args := m.Called(customerID)
+	return args.Error(0)
+}
 func (m *MockCache) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -70,3 +180,150 @@ func TestOrderService_GetOrderByUID_CacheHit(t *testing.T) {
 	mockRepo.AssertNotCalled(t, "FindByOrderUID")
 	mockCache.AssertExpectations(t)
 }
+
+func TestOrderService_CreateOrder_ValidationError(t *testing.T) {
+	mockCache := new(MockCache)
+	mockRepo := new(MockRepo)
+	serv := service.NewOrderService(mockRepo, mockCache)
+
+	_, err := serv.CreateOrder([]byte(`{"track_number":"T1","delivery":{"name":"A"}}`))
+
+	var verr *service.ValidationError
+	assert.ErrorAs(t, err, &verr)
+	assert.Contains(t, verr.Fields, "delivery.phone")
+	assert.Contains(t, verr.Fields, "payment")
+	assert.Contains(t, verr.Fields, "items")
+	mockRepo.AssertNotCalled(t, "Create")
+}
+
+func TestOrderService_CreateOrder_InvalidJSON(t *testing.T) {
+	serv := service.NewOrderService(new(MockRepo), new(MockCache))
+
+	_, err := serv.CreateOrder([]byte("invalid json"))
+
+	assert.ErrorIs(t, err, service.ErrInvalidPayload)
+}
+
+func TestOrderService_ChangeStatus_Transitions(t *testing.T) {
+	mockCache := new(MockCache)
+	mockRepo := new(MockRepo)
+	serv := service.NewOrderService(mockRepo, mockCache)
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	current := &models.Order{OrderUID: "u1", Status: models.StatusCreated}
+	updated := &models.Order{OrderUID: "u1", Status: models.StatusPaid}
+	mockRepo.On("FindByOrderUID", "u1").Return(current, nil)
+	mockRepo.On("ChangeStatus", "u1", models.StatusCreated, models.StatusPaid, "paid online", at).Return(updated, nil)
+	mockCache.On("Set", updated).Return(nil)
+
+	order, err := serv.ChangeStatus("u1", models.StatusPaid, "paid online", at)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPaid, order.Status)
+
+	_, err = serv.ChangeStatus("u1", models.StatusDelivered, "", at)
+	assert.ErrorIs(t, err, service.ErrInvalidTransition)
+	mockRepo.AssertNumberOfCalls(t, "ChangeStatus", 1)
+}
+
+func TestOrderService_ChangeStatus_RereadsAfterConflict(t *testing.T) {
+	mockCache := new(MockCache)
+	mockRepo := new(MockRepo)
+	serv := service.NewOrderService(mockRepo, mockCache)
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	// Реплика отстаёт: первое чтение видит created, хотя заказ уже paid
+	mockRepo.On("FindByOrderUID", "u1").Return(&models.Order{OrderUID: "u1", Status: models.StatusCreated}, nil).Once()
+	mockRepo.On("ChangeStatus", "u1", models.StatusCreated, models.StatusCancelled, "", at).Return(nil, service.ErrStatusConflict).Once()
+	mockRepo.On("FindByOrderUID", "u1").Return(&models.Order{OrderUID: "u1", Status: models.StatusPaid}, nil).Once()
+	updated := &models.Order{OrderUID: "u1", Status: models.StatusCancelled}
+	mockRepo.On("ChangeStatus", "u1", models.StatusPaid, models.StatusCancelled, "", at).Return(updated, nil).Once()
+	mockCache.On("Set", updated).Return(nil)
+
+	order, err := serv.ChangeStatus("u1", models.StatusCancelled, "", at)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCancelled, order.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_ApplyStatusMessage_RetriesPersistentConflict(t *testing.T) {
+	mockRepo := new(MockRepo)
+	serv := service.NewOrderService(mockRepo, new(MockCache))
+
+	mockRepo.On("FindByOrderUID", "u1").Return(&models.Order{OrderUID: "u1", Status: models.StatusCreated}, nil)
+	mockRepo.On("ChangeStatus", "u1", models.StatusCreated, models.StatusPaid, "", mock.Anything).Return(nil, service.ErrStatusConflict)
+
+	err := serv.ApplyStatusMessage([]byte(`{"order_uid":"u1","status":"paid"}`))
+	assert.ErrorIs(t, err, consumer.ErrRetry, "the message must not be committed")
+	assert.ErrorIs(t, err, service.ErrStatusConflict)
+	mockRepo.AssertNumberOfCalls(t, "ChangeStatus", 3)
+}
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, service.CanTransition(models.StatusShipped, models.StatusDelivered))
+	assert.True(t, service.CanTransition(models.StatusDelivered, models.StatusReturned))
+	assert.False(t, service.CanTransition(models.StatusCancelled, models.StatusPaid))
+	assert.False(t, service.CanTransition(models.StatusCreated, models.StatusShipped))
+}
+
+func TestOrderService_DeleteOrder_SoftDeletesAndEvicts(t *testing.T) {
+	mockCache := new(MockCache)
+	mockRepo := new(MockRepo)
+	serv := service.NewOrderService(mockRepo, mockCache)
+
+	mockRepo.On("SoftDelete", "u1", mock.Anything).Return(nil)
+	mockCache.On("Delete", "u1").Return(nil)
+
+	assert.NoError(t, serv.DeleteOrder("u1", "fraud"))
+	mockRepo.AssertNotCalled(t, "ChangeStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestOrderService_CancelOrder_CancelsThenSoftDeletes(t *testing.T) {
+	mockCache := new(MockCache)
+	mockRepo := new(MockRepo)
+	serv := service.NewOrderService(mockRepo, mockCache)
+
+	mockRepo.On("FindByOrderUID", "u1").Return(&models.Order{OrderUID: "u1", Status: models.StatusPaid}, nil)
+	mockRepo.On("ChangeStatus", "u1", models.StatusPaid, models.StatusCancelled, "fraud", mock.Anything).
+		Return(&models.Order{OrderUID: "u1", Status: models.StatusCancelled}, nil)
+	mockRepo.On("SoftDelete", "u1", mock.Anything).Return(nil)
+	mockCache.On("Set", mock.Anything).Return(nil)
+	mockCache.On("Delete", "u1").Return(nil)
+
+	assert.NoError(t, serv.CancelOrder("u1", "fraud"))
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestOrderService_CancelOrder_RejectsShippedOrder(t *testing.T) {
+	mockCache := new(MockCache)
+	mockRepo := new(MockRepo)
+	serv := service.NewOrderService(mockRepo, mockCache)
+
+	mockRepo.On("FindByOrderUID", "u1").Return(&models.Order{OrderUID: "u1", Status: models.StatusShipped}, nil)
+
+	err := serv.CancelOrder("u1", "fraud")
+	assert.ErrorIs(t, err, service.ErrInvalidTransition)
+	mockRepo.AssertNotCalled(t, "SoftDelete", mock.Anything, mock.Anything)
+}
+
+func TestOrderService_CreateOrder_AcceptsSampleModel(t *testing.T) {
+	data, err := os.ReadFile("../../docs/model.json")
+	require.NoError(t, err)
+	mockRepo := new(MockRepo)
+	mockRepo.On("Create", mock.Anything).Return(nil)
+	mockCache := new(MockCache)
+	mockCache.On("Set", mock.Anything).Return(nil)
+	mockCache.On("DeleteCustomerOrderUIDs", mock.Anything).Return(nil).Maybe()
+	serv := service.NewOrderService(mockRepo, mockCache)
+
+	_, err = serv.CreateOrder(data)
+	require.NoError(t, err)
+
+	// Транзакция оплаты не обязана совпадать с order_uid
+	order := loadModelOrder(t)
+	order.Payment.Transaction = "7d1e3a52-0c4b-4f0e-9b1a-2f4c6d8e0a13"
+	order.Status = models.StatusCreated
+	assert.NoError(t, service.ValidateOrder(order))
+}
@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/consumer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collector собирает сообщения, пришедшие из источника
+type collector struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (c *collector) handle(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.msgs = append(c.msgs, string(data))
+	return nil
+}
+
+func TestReaderSource_JSONLAndPrettyJSON(t *testing.T) {
+	input := `{"order_uid":"a"}
+{"order_uid":"b"}
+{
+   "order_uid": "c"
+}
+[{"order_uid":"d"},{"order_uid":"e"}]
+`
+	src := consumer.NewReaderSource(strings.NewReader(input))
+	c := &collector{}
+
+	src.Start(c.handle)
+	<-src.Done()
+
+	assert.Len(t, c.msgs, 5)
+	assert.Contains(t, c.msgs[2], `"c"`)
+	assert.Equal(t, `{"order_uid":"e"}`, c.msgs[4])
+}
+
+func TestReaderSource_StopsReadingWhenGateCloses(t *testing.T) {
+	gate := consumer.NewGate(time.Hour)
+	var calls atomic.Int32
+	handle := gate.Wrap(func([]byte) error {
+		if calls.Add(1) == 2 {
+			gate.Pause()
+			gate.Close()
+		}
+		return nil
+	})
+
+	// Поток не закрывается: источник завершится, только если перестанет читать
+	r, w := io.Pipe()
+	defer w.Close()
+	src := consumer.NewReaderSource(r)
+	src.Start(handle)
+	for i := 0; i < 3; i++ {
+		_, err := w.Write([]byte(`{"order_uid":"` + strconv.Itoa(i) + `"}` + "\n"))
+		require.NoError(t, err)
+	}
+
+	select {
+	case <-src.Done():
+	case <-time.After(time.Second):
+		t.Fatal("source keeps reading after the gate was closed")
+	}
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestChannelSource_DeliversAllMessages(t *testing.T) {
+	src := consumer.NewChannelSource(2)
+	c := &collector{}
+	group := consumer.NewGroup(src)
+
+	group.Start(c.handle)
+	src.Send([]byte(`{"order_uid":"1"}`))
+	src.Send([]byte(`{"order_uid":"2"}`))
+	assert.NoError(t, group.Close())
+	<-src.Done()
+
+	assert.Equal(t, []string{`{"order_uid":"1"}`, `{"order_uid":"2"}`}, c.msgs)
+}
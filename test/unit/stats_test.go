@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/money"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/repository"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockStatsRepo struct{ mock.Mock }
+
+func (m *MockStatsRepo) Summary(filter repository.StatsFilter) (*models.StatsSummary, error) {
+	args := m.Called(filter)
+	if result := args.Get(0); result != nil {
+		return result.(*models.StatsSummary), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+func (m *MockStatsRepo) Aggregate(dimension string, filter repository.StatsFilter) ([]models.StatsBucket, error) {
+	args := m.Called(dimension, filter)
+	if result := args.Get(0); result != nil {
+		return result.([]models.StatsBucket), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// memoryStatsCache — кеш статистики в памяти
+type memoryStatsCache struct {
+	data map[string][]byte
+}
+
+func (c *memoryStatsCache) Get(key string, dst any) (bool, error) {
+	data, ok := c.data[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, dst)
+}
+func (c *memoryStatsCache) Set(key string, value any) error {
+	data, err := json.Marshal(value)
+	c.data[key] = data
+	return err
+}
+func (c *memoryStatsCache) Close() error { return nil }
+
+func TestStatsService_AggregateIsCached(t *testing.T) {
+	repo := new(MockStatsRepo)
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := repository.StatsFilter{From: from, To: from.AddDate(0, 1, 0)}
+	expectedFilter := filter
+	expectedFilter.Limit = 20
+	buckets := []models.StatsBucket{{Key: "meest", Orders: 3, Revenue: money.Money{Amount: 150000, Currency: "RUB"}, Unconverted: 1}}
+	repo.On("Aggregate", models.StatsByDeliveryService, expectedFilter).Return(buckets, nil).Once()
+
+	serv := service.NewStatsService(repo, &memoryStatsCache{data: map[string][]byte{}})
+
+	first, err := serv.Aggregate(models.StatsByDeliveryService, filter)
+	assert.NoError(t, err)
+	second, err := serv.Aggregate(models.StatsByDeliveryService, filter)
+	assert.NoError(t, err)
+
+	assert.Equal(t, buckets, first)
+	assert.Equal(t, buckets, second)
+	repo.AssertExpectations(t)
+}
+
+func TestStatsService_Validation(t *testing.T) {
+	serv := service.NewStatsService(new(MockStatsRepo), &memoryStatsCache{data: map[string][]byte{}})
+	now := time.Now()
+
+	_, err := serv.Aggregate("password", repository.StatsFilter{From: now.Add(-time.Hour), To: now})
+	var verr *service.ValidationError
+	assert.ErrorAs(t, err, &verr)
+	assert.Contains(t, verr.Fields, "dimension")
+
+	_, err = serv.Summary(repository.StatsFilter{From: now, To: now.Add(-time.Hour)})
+	assert.ErrorAs(t, err, &verr)
+	assert.Contains(t, verr.Fields, "from")
+
+	_, err = serv.Summary(repository.StatsFilter{From: now.Add(-time.Hour), To: now, Currency: "usd"})
+	assert.ErrorAs(t, err, &verr)
+	assert.Contains(t, verr.Fields, "currency")
+}
@@ -0,0 +1,151 @@
+package unit
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	v1 "github.com/Sphirium/wb-tech-demo-lo/internal/api/v1"
+	"github.com/Sphirium/wb-tech-demo-lo/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestamp_AcceptsUpstreamFormats(t *testing.T) {
+	expected := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+
+	for input, offset := range map[string]int{
+		`"2021-11-26T06:22:19Z"`:      0,
+		`"2021-11-26T09:22:19+03:00"`: 3 * 60 * 60,
+		`1637907739`:                  0,
+		`1637907739000`:               0,
+		`"1637907739"`:                0,
+	} {
+		var ts models.Timestamp
+		require.NoError(t, json.Unmarshal([]byte(input), &ts), input)
+		assert.True(t, expected.Equal(ts.Time), input)
+		assert.Equal(t, offset, ts.Offset(), input)
+	}
+
+	var ts models.Timestamp
+	assert.Error(t, json.Unmarshal([]byte(`"26.11.2021"`), &ts))
+}
+
+func TestTimestamp_MarshalsRFC3339WithOriginalOffset(t *testing.T) {
+	cases := map[string]string{
+		`"2021-11-26T09:22:19+03:00"`:      `"2021-11-26T09:22:19+03:00"`,
+		`"2021-11-26T01:22:19.5-05:00"`:    `"2021-11-26T01:22:19.5-05:00"`,
+		`"2021-11-26T06:22:19.123456789Z"`: `"2021-11-26T06:22:19.123456Z"`,
+		`1637907739123`:                    `"2021-11-26T06:22:19.123Z"`,
+	}
+	for input, expected := range cases {
+		var ts models.Timestamp
+		require.NoError(t, json.Unmarshal([]byte(input), &ts))
+
+		data, err := json.Marshal(ts)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(data), input)
+	}
+}
+
+func TestUnixTime_MarshalsInOriginalUnit(t *testing.T) {
+	cases := map[string]string{
+		`1637907727`:                   `1637907727`,
+		`1637907727000`:                `1637907727000`,
+		`1637907727250`:                `1637907727250`,
+		`"2021-11-26T06:22:07Z"`:       `1637907727`,
+		`"2021-11-26T06:22:07.25069Z"`: `1637907727250`,
+		`0`:                            `0`,
+	}
+	for input, expected := range cases {
+		var ut models.UnixTime
+		require.NoError(t, json.Unmarshal([]byte(input), &ut), input)
+
+		data, err := json.Marshal(ut)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(data), input)
+	}
+}
+
+func TestOrder_JSONRoundTrip(t *testing.T) {
+	data, err := os.ReadFile("../../docs/model.json")
+	require.NoError(t, err)
+
+	var order models.Order
+	require.NoError(t, json.Unmarshal(data, &order))
+	assert.Equal(t, int64(1637907727), order.Payment.PaymentDt.Unix())
+
+	encoded, err := json.Marshal(&order)
+	require.NoError(t, err)
+
+	var source, result map[string]any
+	require.NoError(t, json.Unmarshal(data, &source))
+	require.NoError(t, json.Unmarshal(encoded, &result))
+	assert.Equal(t, source["date_created"], result["date_created"])
+	assert.Equal(t,
+		source["payment"].(map[string]any)["payment_dt"],
+		result["payment"].(map[string]any)["payment_dt"])
+}
+
+func TestTimestamp_ScanNormalizesToUTC(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	var ts models.Timestamp
+	require.NoError(t, ts.Scan(time.Date(2021, 11, 26, 9, 22, 19, 0, moscow)))
+
+	value, err := ts.Value()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC), value)
+}
+
+// Заказ, прочитанный из БД или из кеша, кодируется так же, как принятый,
+// и имеет ту же версию представления (ETag)
+func TestOrder_TimestampsRoundTripThroughDBAndCache(t *testing.T) {
+	input := map[string]any{}
+	data, err := os.ReadFile("../../docs/model.json")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &input))
+	input["date_created"] = "2021-11-26T09:22:19.123456789+03:00"
+	input["payment"].(map[string]any)["payment_dt"] = json.Number("1637907727000")
+	data, err = json.Marshal(input)
+	require.NoError(t, err)
+
+	var accepted models.Order
+	require.NoError(t, json.Unmarshal(data, &accepted))
+	version := v1.Version(&accepted)
+
+	// БД: хуки GORM раскладывают смещение и единицу по столбцам, TIMESTAMPTZ
+	// хранит только момент (Value/Scan)
+	require.NoError(t, accepted.BeforeSave(nil))
+	require.NoError(t, accepted.Payment.BeforeSave(nil))
+	fromDB := accepted
+	payment := *accepted.Payment
+	fromDB.Payment = &payment
+	value, err := accepted.DateCreated.Value()
+	require.NoError(t, err)
+	require.NoError(t, fromDB.DateCreated.Scan(value))
+	value, err = accepted.Payment.PaymentDt.Value()
+	require.NoError(t, err)
+	fromDB.Payment.PaymentDt = models.UnixTime{}
+	require.NoError(t, fromDB.Payment.PaymentDt.Scan(value))
+	require.NoError(t, fromDB.AfterFind(nil))
+	require.NoError(t, fromDB.Payment.AfterFind(nil))
+
+	// Кеш хранит представление v1
+	cached, err := json.Marshal(v1.FromOrder(&accepted))
+	require.NoError(t, err)
+	var dto v1.Order
+	require.NoError(t, json.Unmarshal(cached, &dto))
+	fromCache := dto.Model()
+
+	for name, order := range map[string]*models.Order{"db": &fromDB, "cache": fromCache} {
+		assert.Equal(t, version, v1.Version(order), name)
+
+		encoded, err := json.Marshal(order)
+		require.NoError(t, err)
+		var result map[string]any
+		require.NoError(t, json.Unmarshal(encoded, &result))
+		assert.Equal(t, "2021-11-26T09:22:19.123456+03:00", result["date_created"], name)
+		assert.Equal(t, float64(1637907727000), result["payment"].(map[string]any)["payment_dt"], name)
+	}
+}